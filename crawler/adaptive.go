@@ -0,0 +1,109 @@
+package crawler
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// adaptiveLatencyFactor is how many times slower than the fastest response a host may get before we back off
+	adaptiveLatencyFactor = 2.0
+	// adaptiveLatencyWeight is the weight of the newest response in the average latency
+	adaptiveLatencyWeight = 0.2
+	// adaptiveMinLatencyDecay is how far the fastest response drifts toward each slower one, so one
+	// unusually fast response (a cached page, a tiny body) is forgotten after a few dozen responses
+	adaptiveMinLatencyDecay = 0.05
+)
+
+// adaptiveLimit is an AIMD concurrency limit for one host. It starts at one request and grows by
+// about one request per round trip while responses stay fast and healthy, and halves on 429, 503,
+// timeouts or latency well above the recent fastest response.
+type adaptiveLimit struct {
+	mu         *sync.Mutex
+	cond       *sync.Cond
+	limit      float64
+	ceiling    int
+	inFlight   int
+	minLatency time.Duration // Fastest recent response, decaying toward slower ones
+	avgLatency time.Duration
+	lastCut    time.Time
+}
+
+// newAdaptiveLimit creates a limit that never grows beyond ceiling
+func newAdaptiveLimit(ceiling int) *adaptiveLimit {
+	mu := &sync.Mutex{}
+	return &adaptiveLimit{mu: mu, cond: sync.NewCond(mu), limit: 1, ceiling: ceiling}
+}
+
+// acquire blocks until a request may start
+func (a *adaptiveLimit) acquire() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for a.inFlight >= int(a.limit) {
+		a.cond.Wait()
+	}
+	a.inFlight++
+}
+
+// release ends a request started with acquire
+func (a *adaptiveLimit) release() {
+	a.mu.Lock()
+	a.inFlight--
+	a.mu.Unlock()
+	a.cond.Broadcast()
+}
+
+// current returns the number of requests currently allowed at once
+func (a *adaptiveLimit) current() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return int(a.limit)
+}
+
+// observe adjusts the limit from one request. A status code of 0 means no response arrived; only timeouts
+// count as congestion then, since DNS, TLS and hook errors say nothing about the host's load.
+// It reports whether the limit was cut.
+func (a *adaptiveLimit) observe(statusCode int, timedOut bool, latency time.Duration, now time.Time) (cut bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	defer a.cond.Broadcast()
+
+	switch {
+	case timedOut, statusCode == http.StatusTooManyRequests, statusCode == http.StatusServiceUnavailable:
+		return a.cut(now)
+	case statusCode == 0:
+		return false
+	}
+
+	if a.minLatency == 0 || latency < a.minLatency {
+		a.minLatency = latency
+	} else {
+		a.minLatency += time.Duration(adaptiveMinLatencyDecay * float64(latency-a.minLatency))
+	}
+	if a.avgLatency == 0 {
+		a.avgLatency = latency
+	} else {
+		a.avgLatency = time.Duration(adaptiveLatencyWeight*float64(latency) + (1-adaptiveLatencyWeight)*float64(a.avgLatency))
+	}
+	if float64(a.avgLatency) > adaptiveLatencyFactor*float64(a.minLatency) {
+		return a.cut(now)
+	}
+
+	// Additive increase: each response adds 1/limit, so a full round trip adds one request
+	a.limit = min(a.limit+1/a.limit, float64(a.ceiling))
+	return false
+}
+
+// cut halves the limit, at most once per round trip so one burst of slow responses counts once
+func (a *adaptiveLimit) cut(now time.Time) bool {
+	if !a.lastCut.IsZero() && now.Sub(a.lastCut) < a.avgLatency {
+		return false
+	}
+	a.lastCut = now
+	a.limit = max(a.limit/2, 1)
+
+	// Forget the latency spike so the average is rebuilt at the new level
+	a.avgLatency = a.minLatency
+	return true
+}
@@ -0,0 +1,129 @@
+package crawler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLimitGrowsToCeiling(t *testing.T) {
+	limit := newAdaptiveLimit(4)
+	now := time.Now()
+
+	if limit.current() != 1 {
+		t.Fatalf("expected to start at 1, got %d", limit.current())
+	}
+	for range 50 {
+		limit.observe(200, false, 100*time.Millisecond, now)
+	}
+	if limit.current() != 4 {
+		t.Errorf("expected healthy responses to reach the ceiling of 4, got %d", limit.current())
+	}
+}
+
+func TestAdaptiveLimitBacksOff(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		timedOut   bool
+		latency    time.Duration
+	}{
+		{"too many requests", 429, false, 100 * time.Millisecond},
+		{"service unavailable", 503, false, 100 * time.Millisecond},
+		{"timeout", 0, true, 30 * time.Second},
+		{"latency spike", 200, false, 5 * time.Second},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			limit := newAdaptiveLimit(8)
+			now := time.Now()
+			for range 100 {
+				limit.observe(200, false, 100*time.Millisecond, now)
+			}
+
+			now = now.Add(time.Second)
+			if !limit.observe(tc.statusCode, tc.timedOut, tc.latency, now) {
+				t.Fatal("expected the limit to be cut")
+			}
+			if limit.current() != 4 {
+				t.Errorf("expected the limit to halve to 4, got %d", limit.current())
+			}
+
+			// A second signal within the same round trip doesn't cut again
+			if limit.observe(429, false, 100*time.Millisecond, now.Add(time.Millisecond)) {
+				t.Error("expected one cut per round trip")
+			}
+		})
+	}
+}
+
+func TestAdaptiveLimitIgnoresFailuresWithoutResponse(t *testing.T) {
+	limit := newAdaptiveLimit(8)
+	now := time.Now()
+	for range 100 {
+		limit.observe(200, false, 100*time.Millisecond, now)
+	}
+
+	// DNS, TLS and hook errors aren't a sign of an overloaded host
+	if limit.observe(0, false, time.Millisecond, now.Add(time.Second)) {
+		t.Error("expected a failure without a response or timeout not to cut the limit")
+	}
+	if limit.current() != 8 {
+		t.Errorf("expected the limit to stay at 8, got %d", limit.current())
+	}
+}
+
+func TestAdaptiveLimitForgetsFastOutlier(t *testing.T) {
+	limit := newAdaptiveLimit(8)
+	now := time.Now()
+	for range 100 {
+		limit.observe(200, false, 100*time.Millisecond, now)
+	}
+
+	// One unusually fast response, e.g. a tiny cached page, cuts the limit at most briefly
+	limit.observe(200, false, time.Millisecond, now)
+	for i := range 200 {
+		limit.observe(200, false, 100*time.Millisecond, now.Add(time.Duration(i)*time.Second))
+	}
+	if limit.current() != 8 {
+		t.Errorf("expected the limit to recover to 8 at normal latency, got %d", limit.current())
+	}
+	if limit.minLatency < 50*time.Millisecond {
+		t.Errorf("expected the fastest latency to drift back toward 100ms, got %v", limit.minLatency)
+	}
+}
+
+func TestAdaptiveLimitNeverBelowOne(t *testing.T) {
+	limit := newAdaptiveLimit(8)
+	now := time.Now()
+	for i := range 10 {
+		limit.observe(503, false, 0, now.Add(time.Duration(i)*time.Second))
+	}
+	if limit.current() != 1 {
+		t.Errorf("expected a floor of 1, got %d", limit.current())
+	}
+}
+
+func TestAdaptiveLimitBlocksAtLimit(t *testing.T) {
+	limit := newAdaptiveLimit(4)
+	limit.acquire()
+
+	acquired := make(chan struct{})
+	go func() {
+		limit.acquire()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("expected the second request to wait at a limit of 1")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	limit.release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("expected the second request to start after a release")
+	}
+}
@@ -0,0 +1,75 @@
+package crawler
+
+import (
+	"sync"
+	"time"
+)
+
+// Why a crawl stopped before running out of pages to crawl
+const (
+	StopMaxPages    = "max_pages"    // Every site used its page budget
+	StopMaxDuration = "max_duration" // The time budget ran out
+	StopMaxBytes    = "max_bytes"    // The download budget ran out
+	StopCancelled   = "cancelled"    // Run's context was cancelled
+)
+
+// crawlBudget is the time and download budget shared by every site in a run. Once it is used up
+// no new pages are started, while pages in flight finish as they do at the page limit.
+type crawlBudget struct {
+	mu       *sync.Mutex
+	deadline time.Time // Zero for no time limit
+	maxBytes int64     // 0 for no download limit
+	bytes    int64
+	reason   string // Which budget ran out first, empty while there is budget left
+}
+
+// newCrawlBudget starts the clock on a budget, or returns nil if neither limit is set
+func newCrawlBudget(maxDuration time.Duration, maxBytes int64) *crawlBudget {
+	if maxDuration <= 0 && maxBytes <= 0 {
+		return nil
+	}
+	budget := &crawlBudget{mu: &sync.Mutex{}, maxBytes: maxBytes}
+	if maxDuration > 0 {
+		budget.deadline = time.Now().Add(maxDuration)
+	}
+	return budget
+}
+
+// addBytes counts downloaded bytes against the budget
+func (b *crawlBudget) addBytes(n int64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bytes += n
+}
+
+// exhausted reports whether the budget has run out, remembering which limit was hit first
+func (b *crawlBudget) exhausted() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.reason == "" {
+		switch {
+		case !b.deadline.IsZero() && !time.Now().Before(b.deadline):
+			b.reason = StopMaxDuration
+		case b.maxBytes > 0 && b.bytes >= b.maxBytes:
+			b.reason = StopMaxBytes
+		}
+	}
+	return b.reason != ""
+}
+
+// stopReason returns which limit ended the crawl, if any
+func (b *crawlBudget) stopReason() string {
+	if b == nil {
+		return ""
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.reason
+}
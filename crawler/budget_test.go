@@ -0,0 +1,51 @@
+package crawler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCrawlBudgetBytes(t *testing.T) {
+	budget := newCrawlBudget(0, 100)
+
+	budget.addBytes(60)
+	if budget.exhausted() {
+		t.Error("expected budget left after 60 of 100 bytes")
+	}
+	budget.addBytes(40)
+	if !budget.exhausted() {
+		t.Error("expected the budget to run out at 100 bytes")
+	}
+	if budget.stopReason() != StopMaxBytes {
+		t.Errorf("expected %s, got %s", StopMaxBytes, budget.stopReason())
+	}
+}
+
+func TestCrawlBudgetDuration(t *testing.T) {
+	budget := newCrawlBudget(10*time.Millisecond, 0)
+	if budget.exhausted() {
+		t.Error("expected time left right after starting")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if !budget.exhausted() {
+		t.Error("expected the time budget to run out")
+	}
+
+	// The first limit hit is the one reported
+	budget.addBytes(1 << 20)
+	if budget.stopReason() != StopMaxDuration {
+		t.Errorf("expected %s, got %s", StopMaxDuration, budget.stopReason())
+	}
+}
+
+func TestCrawlBudgetUnlimited(t *testing.T) {
+	budget := newCrawlBudget(0, 0)
+	if budget != nil {
+		t.Fatal("expected no budget without limits")
+	}
+	budget.addBytes(1 << 30)
+	if budget.exhausted() || budget.stopReason() != "" {
+		t.Error("expected a nil budget to never run out")
+	}
+}
@@ -0,0 +1,61 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+)
+
+// CanonicalLink records a page whose rel=canonical names another URL
+type CanonicalLink struct {
+	URL       string // Canonical URL the page declares
+	CrossHost bool   // The canonical URL is on a different host than the page
+	Alias     bool   // The page was treated as an alias of the canonical URL and not stored
+}
+
+// recordCanonical remembers a page's canonical URL when it names another page, and reports whether
+// the page should be treated as an alias of it. Only canonicals in scope can stand in for a page, and a
+// page whose canonical is itself an alias (A -> B -> A) is kept, so a canonical cycle keeps one page.
+func (cfg *config) recordCanonical(normalizedURL string, pageData PageData) (alias bool) {
+	if pageData.Canonical == "" {
+		return false
+	}
+	canonicalURL, err := url.Parse(pageData.Canonical)
+	if err != nil {
+		return false
+	}
+	canonicalKey, err := cfg.normalization.normalize(pageData.Canonical)
+	if err != nil || canonicalKey == normalizedURL {
+		return false // Self-canonical
+	}
+
+	crossHost := true
+	if pageURL, err := url.Parse(pageData.URL); err == nil {
+		crossHost = !strings.EqualFold(pageURL.Hostname(), canonicalURL.Hostname())
+	}
+	if cfg.canonicalAliases {
+		alias, _ = cfg.scope.check(canonicalURL)
+	}
+
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	if alias && cfg.aliases[canonicalKey] {
+		alias = false
+	}
+	// An alias that can't be recorded is stored as a page instead, so a canonical cycle still keeps one page
+	if alias && !cfg.roomForRecord(len(cfg.aliases)) {
+		alias = false
+	}
+	if alias {
+		if cfg.aliases == nil {
+			cfg.aliases = make(map[string]bool)
+		}
+		cfg.aliases[normalizedURL] = true
+	}
+	if cfg.canonicals == nil {
+		cfg.canonicals = make(map[string]CanonicalLink)
+	}
+	if _, exists := cfg.canonicals[pageData.URL]; exists || cfg.roomForRecord(len(cfg.canonicals)) {
+		cfg.canonicals[pageData.URL] = CanonicalLink{URL: pageData.Canonical, CrossHost: crossHost, Alias: alias}
+	}
+	return alias
+}
@@ -0,0 +1,110 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCrawlerRunCanonicalAliases(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		canonical := map[string]string{
+			"/":      "/",
+			"/print": "/article",
+			"/ext":   "https://other.example/x",
+		}[r.URL.Path]
+
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><head><link rel="canonical" href="%s"></head><body><h1>Page %s</h1><a href="/print">p</a><a href="/ext">e</a></body></html>`, canonical, r.URL.Path)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		aliases    bool
+		pages      []string
+		canonicals map[string]CanonicalLink
+	}{
+		{
+			name:  "canonicals recorded",
+			pages: []string{"", "/ext", "/print"},
+			canonicals: map[string]CanonicalLink{
+				server.URL + "/print": {URL: server.URL + "/article"},
+				server.URL + "/ext":   {URL: "https://other.example/x", CrossHost: true},
+			},
+		},
+		{
+			name:    "aliases crawled as their canonical page",
+			aliases: true,
+			pages:   []string{"", "/article", "/ext"},
+			canonicals: map[string]CanonicalLink{
+				server.URL + "/print": {URL: server.URL + "/article", Alias: true},
+				server.URL + "/ext":   {URL: "https://other.example/x", CrossHost: true},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := New([]Seed{{URL: server.URL}}, WithCanonicalAliases(tc.aliases))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			result, err := c.Run(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			site := result.Sites[0]
+			for _, page := range tc.pages {
+				normalizedURL, _ := normalizeURL(server.URL + page)
+				if _, exists := site.Pages[normalizedURL]; !exists {
+					t.Errorf("expected page %s, got %v", normalizedURL, site.Pages)
+				}
+			}
+			if len(site.Pages) != len(tc.pages) {
+				t.Errorf("expected %d pages, got %d", len(tc.pages), len(site.Pages))
+			}
+			if !reflect.DeepEqual(site.Canonicals, tc.canonicals) {
+				t.Errorf("expected %v, got %v", tc.canonicals, site.Canonicals)
+			}
+		})
+	}
+}
+
+func TestCrawlerRunMutualCanonicals(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		canonical := map[string]string{"/": "/", "/a": "/b", "/b": "/a"}[r.URL.Path]
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><head><link rel="canonical" href="%s"></head><body><h1>Page %s</h1><a href="/a">a</a></body></html>`, canonical, r.URL.Path)
+	}))
+	defer server.Close()
+
+	c, err := New([]Seed{{URL: server.URL}}, WithCanonicalAliases(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// /a is crawled first and becomes an alias of /b, so /b naming /a back keeps /b
+	site := result.Sites[0]
+	normalizedURL, _ := normalizeURL(server.URL + "/b")
+	if _, exists := site.Pages[normalizedURL]; !exists {
+		t.Errorf("expected page %s to be kept, got %v", normalizedURL, site.Pages)
+	}
+	if len(site.Pages) != 2 {
+		t.Errorf("expected 2 pages, got %d", len(site.Pages))
+	}
+	expected := map[string]CanonicalLink{
+		server.URL + "/a": {URL: server.URL + "/b", Alias: true},
+		server.URL + "/b": {URL: server.URL + "/a"},
+	}
+	if !reflect.DeepEqual(site.Canonicals, expected) {
+		t.Errorf("expected %v, got %v", expected, site.Canonicals)
+	}
+}
@@ -0,0 +1,61 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Link types assigned to each a[href] and area[href] during extraction
+const (
+	LinkNavigational = "navigational" // HTTP(S) link to a page, the only type that is crawled
+	LinkFragment     = "fragment"     // Jump within the same page, e.g. #top
+	LinkEmail        = "email"        // mailto: link
+	LinkPhone        = "phone"        // tel: link
+	LinkScript       = "script"       // javascript: link
+	LinkData         = "data"         // data: URI
+	LinkOther        = "other"        // Any other scheme, e.g. ftp: or sms:
+)
+
+// classifyLink decides the type of an href, given the URL it resolves to and the URL of the page it is on
+func classifyLink(href string, resolved, pageURL *url.URL) string {
+	switch strings.ToLower(resolved.Scheme) {
+	case "http", "https":
+		// An empty href or a fragment that resolves back to the page itself only jumps within it.
+		// With a <base href> that isn't the page, #top leads to another page.
+		href = strings.TrimSpace(href)
+		if (href == "" || strings.Contains(href, "#")) && sameDocument(resolved, pageURL) {
+			return LinkFragment
+		}
+		return LinkNavigational
+	case "mailto":
+		return LinkEmail
+	case "tel":
+		return LinkPhone
+	case "javascript":
+		return LinkScript
+	case "data":
+		return LinkData
+	default:
+		return LinkOther
+	}
+}
+
+// sameDocument reports whether two URLs differ in their fragment at most
+func sameDocument(a, b *url.URL) bool {
+	withoutFragment := func(u *url.URL) string {
+		stripped := *u
+		stripped.Fragment, stripped.RawFragment = "", ""
+		return stripped.String()
+	}
+	return withoutFragment(a) == withoutFragment(b)
+}
+
+// contactAddress returns the address of a mailto: or tel: link, without its scheme or parameters
+func contactAddress(rawURL string) string {
+	_, address, _ := strings.Cut(rawURL, ":")
+	address, _, _ = strings.Cut(address, "?")
+	if unescaped, err := url.PathUnescape(address); err == nil {
+		address = unescaped
+	}
+	return strings.TrimSpace(address)
+}
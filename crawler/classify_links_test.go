@@ -0,0 +1,105 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestGetLinksFromHTMLClassifies(t *testing.T) {
+	inputBody := `<html><body>
+		<a href="/about">About</a>
+		<a href="https://other.com/x#part">Other</a>
+		<a href="#top">Top</a>
+		<a href="">Self</a>
+		<a href="post#comments">Comments</a>
+		<a href="mailto:Team@Example.com?subject=hi">Mail</a>
+		<a href="tel:+1-555-0100">Call</a>
+		<a href="javascript:void(0)">Menu</a>
+		<a href="data:text/plain,hi">Data</a>
+		<a href="ftp://files.example.com/a">FTP</a>
+	</body></html>`
+
+	baseURL, err := url.Parse("https://blog.boot.dev/post")
+	if err != nil {
+		t.Fatalf("couldn't parse input URL: %v", err)
+	}
+	actual, err := getLinksFromHTML(inputBody, baseURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Link{
+		{URL: "https://blog.boot.dev/about", Text: "About", Element: "a", Attribute: "href", Type: LinkNavigational},
+		{URL: "https://other.com/x#part", Text: "Other", Element: "a", Attribute: "href", Type: LinkNavigational},
+		{URL: "https://blog.boot.dev/post#top", Text: "Top", Element: "a", Attribute: "href", Type: LinkFragment},
+		{URL: "https://blog.boot.dev/post", Text: "Self", Element: "a", Attribute: "href", Type: LinkFragment},
+		{URL: "https://blog.boot.dev/post#comments", Text: "Comments", Element: "a", Attribute: "href", Type: LinkFragment},
+		{URL: "mailto:Team@Example.com?subject=hi", Text: "Mail", Element: "a", Attribute: "href", Type: LinkEmail},
+		{URL: "tel:+1-555-0100", Text: "Call", Element: "a", Attribute: "href", Type: LinkPhone},
+		{URL: "javascript:void(0)", Text: "Menu", Element: "a", Attribute: "href", Type: LinkScript},
+		{URL: "data:text/plain,hi", Text: "Data", Element: "a", Attribute: "href", Type: LinkData},
+		{URL: "ftp://files.example.com/a", Text: "FTP", Element: "a", Attribute: "href", Type: LinkOther},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	// Only the navigational links are crawled
+	urls, err := getURLsFromHTML(inputBody, baseURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expectedURLs := []string{"https://blog.boot.dev/about", "https://other.com/x#part"}; !reflect.DeepEqual(urls, expectedURLs) {
+		t.Errorf("expected %v, got %v", expectedURLs, urls)
+	}
+}
+
+func TestContactAddress(t *testing.T) {
+	tests := []struct {
+		inputURL string
+		expected string
+	}{
+		{"mailto:team@example.com", "team@example.com"},
+		{"mailto:team@example.com?subject=hi", "team@example.com"},
+		{"mailto:a%20b@example.com", "a b@example.com"},
+		{"tel:+1-555-0100", "+1-555-0100"},
+	}
+	for _, tc := range tests {
+		if actual := contactAddress(tc.inputURL); actual != tc.expected {
+			t.Errorf("expected %v, got %v", tc.expected, actual)
+		}
+	}
+}
+
+func TestCrawlerRunFollowsOnlyNavigationalLinks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><h1>Page %s</h1><a href="/a">a</a><a href="#top">top</a><a href="mailto:team@example.com">mail</a><a href="tel:+15550100">call</a><a href="javascript:void(0)">menu</a></body></html>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	c, err := New([]Seed{{URL: server.URL}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	site := result.Sites[0]
+	if len(site.Pages) != 2 {
+		t.Errorf("expected 2 pages, got %d", len(site.Pages))
+	}
+	if len(site.OutOfScope) != 0 {
+		t.Errorf("expected non-HTTP links not to be queued, got out of scope %v", site.OutOfScope)
+	}
+	if result.Stats.Skipped[skipVisited] != 1 {
+		t.Errorf("expected only /a to be queued twice, got skips %v", result.Stats.Skipped)
+	}
+}
@@ -0,0 +1,409 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// config struct for concurrent crawling
+type config struct {
+	pages              map[string]PageData // Crawled pages, unused when streaming
+	visited            VisitedSet          // Every normalized URL we've started crawling
+	baseURL            *url.URL
+	mu                 *sync.Mutex
+	concurrencyControl chan struct{}
+	wg                 *sync.WaitGroup
+	maxPages           int             // Maximum number of pages to crawl
+	ctx                context.Context // Cancelled when the crawl should wind down
+	scope              *scopeRules     // Decides which URLs belong to the crawl
+	outOfScope         map[string]string
+	site               string                         // Site name used in combined reports
+	maxPerHost         int                            // Concurrent requests allowed per host, 0 for no limit
+	hostLimits         map[string]chan struct{}       // Per-host concurrency control
+	adaptiveCeiling    int                            // Most concurrent requests per host with adaptive concurrency, 0 to disable
+	adaptiveLimits     map[string]*adaptiveLimit      // Host -> adaptive concurrency limit
+	traps              *trapDetector                  // Cuts off crawler traps, nil to disable
+	contentHashes      map[string]string              // Content hash -> first page URL with that content
+	log                io.Writer                      // Progress output, nil to discard
+	hooks              *hooks                         // User hooks run around each page, nil for none
+	stream             func(PageResult)               // Receives each page as it completes instead of pages
+	pageCount          int                            // Pages stored or streamed so far
+	schedule           func(rawURL string, depth int) // Queues discovered URLs elsewhere, nil to crawl them here
+	previous           map[string]PageData            // Pages from the previous crawl, nil unless incremental
+	sitemapLastMods    map[string]time.Time           // Normalized URL -> sitemap lastmod, for incremental crawls
+	changes            map[string]string              // Normalized URL -> change since the previous crawl
+	unchanged          int                            // Pages that match the previous crawl
+	budget             *crawlBudget                   // Time and download budget shared by every site, nil for none
+	stats              *statsCollector                // Run statistics shared by every site, nil to skip
+	normalization      URLNormalization               // How URLs are reduced to page keys
+	stripped           map[string]StrippedURL         // Discovered URL -> URL queued without tracking parameters
+	canonicalAliases   bool                           // Treat pages with a non-self canonical as aliases instead of storing them
+	canonicals         map[string]CanonicalLink       // Page URL -> canonical URL it declares, when that is another page
+	aliases            map[string]bool                // Normalized URLs of pages treated as canonical aliases
+	skipNofollow       bool                           // Don't follow links whose every occurrence on a page is rel=nofollow
+	maxRecords         int                            // Most entries in each per-URL record, 0 or less for no limit
+	recordsDropped     int                            // Entries left out of those records once they were full
+}
+
+// logf writes a progress message if the crawl has a log output
+func (cfg *config) logf(format string, args ...any) {
+	if cfg.log != nil {
+		fmt.Fprintf(cfg.log, format, args...)
+	}
+}
+
+// stopped reports whether a shutdown has been requested
+func (cfg *config) stopped() bool {
+	if cfg.ctx == nil {
+		return false
+	}
+	select {
+	case <-cfg.ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// addPageVisit helper method
+func (cfg *config) addPageVisit(normalizedURL string) (isFirst bool) {
+	isNew, err := cfg.visitedSet().Add(normalizedURL)
+	if err != nil {
+		// Treat the page as visited rather than risk crawling it repeatedly
+		cfg.logf("error recording visit to %s: %v\n", normalizedURL, err)
+		return false
+	}
+	return isNew
+}
+
+// visitedSet returns the site's visited set, defaulting to an in-memory set
+func (cfg *config) visitedSet() VisitedSet {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+
+	if cfg.visited == nil {
+		cfg.visited = NewMemoryVisitedSet()
+	}
+	return cfg.visited
+}
+
+// storePage hands a completed page to the stream, or keeps it in pages when not streaming
+func (cfg *config) storePage(normalizedURL string, pageData PageData) {
+	cfg.mu.Lock()
+	cfg.pageCount++
+	if cfg.stream == nil {
+		cfg.pages[normalizedURL] = pageData
+	}
+	cfg.mu.Unlock()
+
+	if cfg.stream != nil {
+		cfg.stream(PageResult{Site: cfg.site, NormalizedURL: normalizedURL, Page: pageData})
+	}
+}
+
+// recordOutOfScope remembers the rule that kept a URL out of the crawl
+func (cfg *config) recordOutOfScope(rawURL, rule string) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+
+	if cfg.outOfScope == nil {
+		cfg.outOfScope = make(map[string]string)
+	}
+	if _, exists := cfg.outOfScope[rawURL]; !exists && cfg.roomForRecord(len(cfg.outOfScope)) {
+		cfg.outOfScope[rawURL] = rule
+	}
+}
+
+// recordStripped remembers which parameters were removed from a discovered URL
+func (cfg *config) recordStripped(rawURL, strippedURL string, params []string) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+
+	if cfg.stripped == nil {
+		cfg.stripped = make(map[string]StrippedURL)
+	}
+	if _, exists := cfg.stripped[rawURL]; exists || cfg.roomForRecord(len(cfg.stripped)) {
+		cfg.stripped[rawURL] = StrippedURL{URL: strippedURL, Params: params}
+	}
+}
+
+// roomForRecord reports whether a record holding size entries may take another one under maxRecords,
+// counting the entry as dropped when it can't; callers hold cfg.mu
+func (cfg *config) roomForRecord(size int) bool {
+	if cfg.maxRecords <= 0 || size < cfg.maxRecords {
+		return true
+	}
+	if cfg.recordsDropped == 0 {
+		cfg.logf("record limit of %d reached for %s, further report entries are dropped\n", cfg.maxRecords, cfg.site)
+	}
+	cfg.recordsDropped++
+	return false
+}
+
+// acquireHost blocks until a request to host may start and returns its release func
+func (cfg *config) acquireHost(host string) (release func()) {
+	if cfg.adaptiveCeiling > 0 {
+		limit := cfg.adaptiveLimit(host)
+		limit.acquire()
+		return limit.release
+	}
+	if cfg.maxPerHost < 1 {
+		return func() {}
+	}
+
+	cfg.mu.Lock()
+	if cfg.hostLimits == nil {
+		cfg.hostLimits = make(map[string]chan struct{})
+	}
+	limit, exists := cfg.hostLimits[host]
+	if !exists {
+		limit = make(chan struct{}, cfg.maxPerHost)
+		cfg.hostLimits[host] = limit
+	}
+	cfg.mu.Unlock()
+
+	limit <- struct{}{}
+	return func() { <-limit }
+}
+
+// adaptiveLimit returns the adaptive concurrency limit of a host, creating it on first use
+func (cfg *config) adaptiveLimit(host string) *adaptiveLimit {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+
+	if cfg.adaptiveLimits == nil {
+		cfg.adaptiveLimits = make(map[string]*adaptiveLimit)
+	}
+	limit, exists := cfg.adaptiveLimits[host]
+	if !exists {
+		limit = newAdaptiveLimit(cfg.adaptiveCeiling)
+		cfg.adaptiveLimits[host] = limit
+	}
+	return limit
+}
+
+// observeHost feeds a response back into the host's adaptive concurrency limit
+func (cfg *config) observeHost(host string, fetched fetchResult, latency time.Duration) {
+	if cfg.adaptiveCeiling < 1 {
+		return
+	}
+	limit := cfg.adaptiveLimit(host)
+	statusCode := fetched.statusCode
+	if limit.observe(statusCode, fetched.errorCategory == "timeout", latency, time.Now()) {
+		cfg.logf("backing off %s: concurrency cut to %d (status %d, %v)\n", host, limit.current(), statusCode, latency.Round(time.Millisecond))
+	}
+}
+
+// crawlPage method - depth is the number of links followed from the seed
+func (cfg *config) crawlPage(rawCurrentURL string, depth int) {
+	defer cfg.wg.Done() // Decrement wait group however we return
+
+	currentURL, normalizedURL, ok := cfg.admit(rawCurrentURL)
+	if !ok {
+		return
+	}
+
+	// Pages the sitemap says are unchanged are carried over without a request
+	previous, hasPrevious := cfg.previous[normalizedURL]
+	if hasPrevious && cfg.unchangedInSitemap(normalizedURL, previous) {
+		cfg.logf("unchanged in sitemap: %s\n", rawCurrentURL)
+		cfg.carryOver(normalizedURL, depth, previous)
+		return
+	}
+
+	// Wait for a per-host slot first so we don't hold a shared pool slot while blocked
+	releaseHost := cfg.acquireHost(currentURL.Host)
+	defer releaseHost()
+
+	// Send to concurrency control channel (shared by every site in the run)
+	cfg.concurrencyControl <- struct{}{}
+	defer func() { <-cfg.concurrencyControl }()
+
+	// A shutdown may have been requested, or the budget used up, while we waited for a slot
+	if cfg.stopped() {
+		cfg.stats.recordSkip(skipShutdown)
+		return
+	}
+	if cfg.budget.exhausted() {
+		cfg.stats.recordSkip(skipRunBudget)
+		return
+	}
+
+	cfg.logf("crawling: %s\n", rawCurrentURL)
+
+	// Get HTML, conditionally if we have the page from a previous crawl
+	var conditional *PageData
+	if hasPrevious {
+		conditional = &previous
+	}
+	start := time.Now()
+	fetched, err := fetchPage(rawCurrentURL, cfg.hooks, conditional)
+	latency := time.Since(start)
+	cfg.observeHost(currentURL.Host, fetched, latency)
+	cfg.budget.addBytes(fetched.bytes)
+	cfg.stats.recordFetch(fetched, latency, err)
+	if err != nil {
+		cfg.logf("error fetching HTML from %s: %v\n", rawCurrentURL, err)
+		cfg.stats.recordSkip(skipFetchFailed)
+		return
+	}
+	if fetched.notModified {
+		cfg.logf("not modified: %s\n", rawCurrentURL)
+		cfg.carryOver(normalizedURL, depth, previous)
+		return
+	}
+
+	// Extract page data in one parse, following the links as extracted even if page hooks change them
+	pageData := extractPageData(fetched.html, rawCurrentURL)
+	pageData.ETag, pageData.LastModified, pageData.CrawledAt = fetched.etag, fetched.lastModified, time.Now()
+	urls := append([]string(nil), pageData.OutgoingLinks...)
+
+	cfg.processPage(normalizedURL, depth, pageData, urls)
+}
+
+// admit decides whether a URL should be crawled, marking it visited if so
+func (cfg *config) admit(rawCurrentURL string) (currentURL *url.URL, normalizedURL string, ok bool) {
+	// Check if we've hit maxPages limit, used up the run's budget or are shutting down at the very start
+	switch {
+	case cfg.stopped():
+		cfg.stats.recordSkip(skipShutdown)
+		return nil, "", false
+	case cfg.visitedSet().Len() >= cfg.maxPages:
+		cfg.stats.recordSkip(skipPageBudget)
+		return nil, "", false
+	case cfg.budget.exhausted():
+		cfg.stats.recordSkip(skipRunBudget)
+		return nil, "", false
+	}
+
+	// Parse current URL
+	currentURL, err := url.Parse(rawCurrentURL)
+	if err != nil {
+		cfg.logf("error parsing URL %s: %v\n", rawCurrentURL, err)
+		cfg.stats.recordSkip(skipInvalidURL)
+		return nil, "", false
+	}
+
+	// Check the URL against the crawl scope
+	if inScope, rule := cfg.scope.check(currentURL); !inScope {
+		cfg.recordOutOfScope(rawCurrentURL, rule)
+		cfg.stats.recordSkip(skipOutOfScope)
+		return nil, "", false
+	}
+
+	// Normalize URL
+	normalizedURL, err = cfg.normalization.normalize(rawCurrentURL)
+	if err != nil {
+		cfg.logf("error normalizing URL %s: %v\n", rawCurrentURL, err)
+		cfg.stats.recordSkip(skipInvalidURL)
+		return nil, "", false
+	}
+
+	// Check if already visited using helper method
+	if !cfg.addPageVisit(normalizedURL) {
+		cfg.stats.recordSkip(skipVisited)
+		return nil, "", false // Already crawled this page
+	}
+
+	// Check again if we've hit maxPages after adding this page
+	if cfg.visitedSet().Len() > cfg.maxPages {
+		cfg.stats.recordSkip(skipPageBudget)
+		return nil, "", false
+	}
+
+	return currentURL, normalizedURL, true
+}
+
+// processPage runs the page hooks, stores the page and queues the links worth following
+func (cfg *config) processPage(normalizedURL string, depth int, pageData PageData, urls []string) {
+	// An alias is crawled as its canonical page instead, so queue that along with its links
+	if cfg.recordCanonical(normalizedURL, pageData) {
+		cfg.logf("canonical alias: %s -> %s\n", pageData.URL, pageData.Canonical)
+		cfg.stats.recordSkip(skipCanonicalAlias)
+		cfg.followLinks(pageData, depth, append([]string{pageData.Canonical}, urls...))
+		return
+	}
+
+	cfg.recordChange(normalizedURL, pageData)
+
+	// Let hooks enrich or drop the page
+	keep := cfg.hooks.page(&pageData)
+
+	// Stream or store page data, or forget it while keeping the page visited
+	isDuplicate := false
+	if keep {
+		isDuplicate = cfg.markDuplicate(&pageData)
+		cfg.storePage(normalizedURL, pageData)
+		cfg.stats.recordPage(depth)
+	} else {
+		cfg.logf("page dropped by hook: %s\n", pageData.URL)
+		cfg.stats.recordSkip(skipPageHook)
+	}
+
+	// Duplicate content has the same links as its canonical page, so don't expand them again
+	if isDuplicate {
+		cfg.logf("duplicate content: %s matches %s\n", pageData.URL, pageData.DuplicateOf)
+		cfg.stats.recordSkip(skipDuplicate)
+		return
+	}
+
+	cfg.followLinks(pageData, depth, urls)
+}
+
+// followLinks queues each URL on a page that the link hooks accept
+func (cfg *config) followLinks(pageData PageData, depth int, urls []string) {
+	// Don't queue up more work once a shutdown has been requested or the budget is used up
+	if cfg.stopped() || cfg.budget.exhausted() {
+		return
+	}
+
+	var nofollow map[string]bool
+	if cfg.skipNofollow {
+		nofollow = nofollowURLs(pageData.Links)
+	}
+	for _, nextURL := range urls {
+		if nofollow[nextURL] {
+			cfg.stats.recordSkip(skipNofollow)
+			continue
+		}
+		if !cfg.hooks.link(pageData, nextURL) {
+			cfg.stats.recordSkip(skipLinkHook)
+			continue
+		}
+		cfg.enqueue(nextURL, depth+1)
+	}
+}
+
+// enqueue schedules a discovered URL for crawling, without tracking parameters, unless it looks like a crawler trap
+func (cfg *config) enqueue(rawURL string, depth int) {
+	// Drop tracking and session parameters so they don't turn one page into many URLs
+	if strippedURL, params := cfg.normalization.stripParams(rawURL); len(params) > 0 {
+		cfg.recordStripped(rawURL, strippedURL, params)
+		rawURL = strippedURL
+	}
+
+	// Only in-scope URLs can be traps, crawlPage records everything else
+	if u, err := url.Parse(rawURL); err == nil {
+		if inScope, _ := cfg.scope.check(u); inScope {
+			if trapped, reason := cfg.traps.check(u); trapped {
+				cfg.logf("skipping likely crawler trap %s: %s\n", rawURL, reason)
+				cfg.stats.recordSkip(skipTrap)
+				return
+			}
+		}
+	}
+
+	// A coordinator queues URLs for workers instead of crawling them here
+	if cfg.schedule != nil {
+		cfg.schedule(rawURL, depth)
+		return
+	}
+
+	// wg.Add before spawning as per tips
+	cfg.wg.Add(1)
+	go cfg.crawlPage(rawURL, depth)
+}
@@ -0,0 +1,220 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func TestAddPageVisit(t *testing.T) {
+	// Create a test config
+	cfg := &config{
+		pages:    make(map[string]PageData),
+		mu:       &sync.Mutex{},
+		maxPages: 10,
+	}
+
+	// Test first visit
+	isFirst := cfg.addPageVisit("example.com")
+	if !isFirst {
+		t.Errorf("expected first visit to return true, got false")
+	}
+
+	// Check it was added to the visited set
+	if cfg.visited.Len() != 1 {
+		t.Errorf("expected 1 page in visited set, got %d", cfg.visited.Len())
+	}
+
+	// Test second visit to same page
+	isFirst = cfg.addPageVisit("example.com")
+	if isFirst {
+		t.Errorf("expected second visit to return false, got true")
+	}
+
+	// Should still only have 1 page
+	if cfg.visited.Len() != 1 {
+		t.Errorf("expected 1 page in visited set after duplicate, got %d", cfg.visited.Len())
+	}
+}
+
+func TestConfigWithMaxPages(t *testing.T) {
+	baseURL, err := url.Parse("https://example.com")
+	if err != nil {
+		t.Fatalf("error parsing base URL: %v", err)
+	}
+
+	maxPages := 5
+	cfg := &config{
+		pages:              make(map[string]PageData),
+		baseURL:            baseURL,
+		mu:                 &sync.Mutex{},
+		concurrencyControl: make(chan struct{}, 3),
+		wg:                 &sync.WaitGroup{},
+		maxPages:           maxPages,
+	}
+
+	// Test that maxPages is set correctly
+	if cfg.maxPages != maxPages {
+		t.Errorf("expected maxPages to be %d, got %d", maxPages, cfg.maxPages)
+	}
+
+	// Test adding pages up to the limit
+	for i := 0; i < maxPages; i++ {
+		url := fmt.Sprintf("example.com/page%d", i)
+		isFirst := cfg.addPageVisit(url)
+		if !isFirst {
+			t.Errorf("expected first visit to page %d to return true", i)
+		}
+	}
+
+	if cfg.visited.Len() != maxPages {
+		t.Errorf("expected %d pages in visited set, got %d", maxPages, cfg.visited.Len())
+	}
+}
+
+func TestMaxPagesCheck(t *testing.T) {
+	cfg := &config{
+		pages:    make(map[string]PageData),
+		mu:       &sync.Mutex{},
+		maxPages: 2,
+	}
+
+	// Add pages up to the limit
+	cfg.addPageVisit("page1.com")
+	cfg.addPageVisit("page2.com")
+
+	// Check if we've hit the limit
+	hitLimit := cfg.visited.Len() >= cfg.maxPages
+
+	if !hitLimit {
+		t.Error("expected to hit maxPages limit with 2 pages")
+	}
+
+	// Try to add another page
+	cfg.addPageVisit("page3.com")
+
+	// Should still only have 2 pages if we properly respect the limit
+	// Note: addPageVisit doesn't check the limit, that's done in crawlPage
+	if cfg.visited.Len() != 3 {
+		t.Errorf("addPageVisit should still add pages (limit checking is in crawlPage), got %d pages", cfg.visited.Len())
+	}
+}
+
+func TestConcurrentAddPageVisit(t *testing.T) {
+	cfg := &config{
+		pages:    make(map[string]PageData),
+		mu:       &sync.Mutex{},
+		maxPages: 100, // Set high so we don't hit limit in test
+	}
+
+	// Test concurrent access to addPageVisit
+	var wg sync.WaitGroup
+	numGoroutines := 10
+
+	// All goroutines try to add the same URL
+	for i := 0; i < numGoroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cfg.addPageVisit("example.com")
+		}()
+	}
+
+	wg.Wait()
+
+	// Should only have 1 page (first one wins, others return false)
+	if cfg.visited.Len() != 1 {
+		t.Errorf("expected 1 page after concurrent access, got %d", cfg.visited.Len())
+	}
+}
+
+func TestCrawlPageAfterShutdown(t *testing.T) {
+	baseURL, err := url.Parse("https://example.com")
+	if err != nil {
+		t.Fatalf("error parsing base URL: %v", err)
+	}
+
+	// Simulate a crawl that has already received a shutdown signal
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cfg := &config{
+		pages:              make(map[string]PageData),
+		baseURL:            baseURL,
+		mu:                 &sync.Mutex{},
+		concurrencyControl: make(chan struct{}, 1),
+		wg:                 &sync.WaitGroup{},
+		maxPages:           10,
+		ctx:                ctx,
+	}
+
+	cfg.wg.Add(1)
+	cfg.crawlPage("https://example.com", 0)
+	cfg.wg.Wait()
+
+	if len(cfg.pages) != 0 {
+		t.Errorf("expected no pages to be crawled after shutdown, got %d", len(cfg.pages))
+	}
+}
+
+func TestCrawlMultipleSitesSharedPool(t *testing.T) {
+	// Two tiny sites that link to each other
+	var siteA, siteB *httptest.Server
+	siteA = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><h1>A</h1><a href="/about">About</a><a href="%s/">B</a></body></html>`, siteB.URL)
+	}))
+	defer siteA.Close()
+	siteB = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><h1>B</h1><a href="%s/">A</a></body></html>`, siteA.URL)
+	}))
+	defer siteB.Close()
+
+	concurrencyControl := make(chan struct{}, 2)
+	wg := &sync.WaitGroup{}
+
+	sites := []*config{}
+	for _, rawURL := range []string{siteA.URL, siteB.URL} {
+		baseURL, err := url.Parse(rawURL)
+		if err != nil {
+			t.Fatalf("error parsing base URL: %v", err)
+		}
+		scope, err := newScopeRules(baseURL, nil, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("error building scope: %v", err)
+		}
+		sites = append(sites, &config{
+			pages:              make(map[string]PageData),
+			baseURL:            baseURL,
+			mu:                 &sync.Mutex{},
+			concurrencyControl: concurrencyControl,
+			wg:                 wg,
+			maxPages:           10,
+			scope:              scope,
+			site:               baseURL.Host,
+			maxPerHost:         1,
+		})
+	}
+
+	for _, cfg := range sites {
+		wg.Add(1)
+		go cfg.crawlPage(cfg.baseURL.String(), 0)
+	}
+	wg.Wait()
+
+	// Each site stays in its own scope and records the other as out of scope
+	if len(sites[0].pages) != 2 {
+		t.Errorf("expected 2 pages for site A, got %d", len(sites[0].pages))
+	}
+	if len(sites[1].pages) != 1 {
+		t.Errorf("expected 1 page for site B, got %d", len(sites[1].pages))
+	}
+	if len(sites[0].outOfScope) != 1 || len(sites[1].outOfScope) != 1 {
+		t.Errorf("expected one out-of-scope URL per site, got %v and %v", sites[0].outOfScope, sites[1].outOfScope)
+	}
+}
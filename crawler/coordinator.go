@@ -0,0 +1,413 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultLeaseTimeout is how long a worker has to submit a leased batch before it is reassigned
+const DefaultLeaseTimeout = 2 * time.Minute
+
+// errWorkerFetch marks a fetch a worker reported as failed
+var errWorkerFetch = errors.New("worker failed to fetch page")
+
+// defaultLeaseSize is the batch size handed out when a worker doesn't ask for one
+const defaultLeaseSize = 10
+
+// Coordinator owns the frontier and visited sets of a distributed crawl and serves them to workers
+// over HTTP/JSON. Workers lease batches of URLs, crawl them and submit the pages and links they found.
+type Coordinator struct {
+	crawler      *Crawler
+	leaseTimeout time.Duration
+	mux          *http.ServeMux
+
+	mu          *sync.Mutex
+	ctx         context.Context
+	sites       []*config
+	frontier    []frontierItem    // Admitted URLs waiting to be leased
+	leases      map[string]*lease // Lease ID -> outstanding lease
+	nextLeaseID int
+	finished    bool
+	done        chan struct{}   // Closed once the crawl is finished
+	workers     map[string]bool // Worker ID -> whether it has been told the crawl is done
+	released    chan struct{}   // Closed once every worker that asked for work has been told the crawl is done
+}
+
+// frontierItem is a URL that passed scope, trap and visited checks and is ready to crawl
+type frontierItem struct {
+	site          int // Index into Coordinator.sites
+	rawURL        string
+	normalizedURL string
+	depth         int
+}
+
+// lease is a batch of URLs handed to one worker
+type lease struct {
+	workerID   string
+	items      []frontierItem
+	expiresAt  time.Time
+	submitting bool // Results are being processed, so the lease must not expire
+}
+
+// leaseRequest asks the coordinator for up to Max URLs
+type leaseRequest struct {
+	WorkerID string `json:"worker_id"`
+	Max      int    `json:"max"`
+}
+
+// leaseResponse hands a worker a batch of URLs. An empty batch means "ask again later",
+// and Done means the crawl is over and the worker can exit.
+type leaseResponse struct {
+	LeaseID   string    `json:"lease_id,omitempty"`
+	URLs      []string  `json:"urls"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	Done      bool      `json:"done"`
+}
+
+// submitRequest returns the results of a lease
+type submitRequest struct {
+	LeaseID  string          `json:"lease_id"`
+	WorkerID string          `json:"worker_id"`
+	Pages    []submittedPage `json:"pages"`
+	Failed   []failedURL     `json:"failed"`
+}
+
+// submittedPage is one crawled page and the links discovered on it
+type submittedPage struct {
+	URL   string      `json:"url"`
+	Page  PageData    `json:"page"`
+	Links []string    `json:"links"`
+	Fetch fetchReport `json:"fetch"`
+}
+
+// failedURL is a leased URL the worker couldn't crawl
+type failedURL struct {
+	URL   string      `json:"url"`
+	Fetch fetchReport `json:"fetch"`
+}
+
+// fetchReport is how a worker's request went, so the coordinator's statistics and byte budget cover it
+type fetchReport struct {
+	StatusCode    int           `json:"status_code,omitempty"` // 0 if no response arrived
+	ContentType   string        `json:"content_type,omitempty"`
+	ErrorCategory string        `json:"error_category,omitempty"`
+	Bytes         int64         `json:"bytes"`
+	Latency       time.Duration `json:"latency_ns"`
+}
+
+// newFetchReport summarizes a fetch for the coordinator
+func newFetchReport(fetched fetchResult, latency time.Duration) fetchReport {
+	return fetchReport{
+		StatusCode:    fetched.statusCode,
+		ContentType:   fetched.contentType,
+		ErrorCategory: fetched.errorCategory,
+		Bytes:         fetched.bytes,
+		Latency:       latency,
+	}
+}
+
+// record counts a worker's fetch against the site's budget and statistics, as crawlPage does for local fetches
+func (r fetchReport) record(cfg *config, err error) {
+	cfg.budget.addBytes(r.Bytes)
+	fetched := fetchResult{statusCode: r.StatusCode, contentType: r.ContentType, errorCategory: r.ErrorCategory, bytes: r.Bytes}
+	cfg.stats.recordFetch(fetched, r.Latency, err)
+}
+
+// coordinatorStatus is served on /status for monitoring
+type coordinatorStatus struct {
+	Frontier int  `json:"frontier"`
+	Leases   int  `json:"leases"`
+	Pages    int  `json:"pages"`
+	Done     bool `json:"done"`
+}
+
+// NewCoordinator creates a coordinator for the crawler's seeds. Serve it with an http.Server and call Run.
+// Workers always fetch pages in full: WithPreviousResults only feeds the changes report, without
+// conditional requests or sitemap lastmod dates.
+func (c *Crawler) NewCoordinator(leaseTimeout time.Duration) *Coordinator {
+	if leaseTimeout <= 0 {
+		leaseTimeout = DefaultLeaseTimeout
+	}
+
+	co := &Coordinator{
+		crawler:      c,
+		leaseTimeout: leaseTimeout,
+		mux:          http.NewServeMux(),
+		mu:           &sync.Mutex{},
+		leases:       make(map[string]*lease),
+		done:         make(chan struct{}),
+		workers:      make(map[string]bool),
+		released:     make(chan struct{}),
+	}
+	co.mux.HandleFunc("POST /lease", co.handleLease)
+	co.mux.HandleFunc("POST /submit", co.handleSubmit)
+	co.mux.HandleFunc("GET /status", co.handleStatus)
+	return co
+}
+
+// ServeHTTP serves the worker API: POST /lease, POST /submit and GET /status
+func (co *Coordinator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	co.mux.ServeHTTP(w, r)
+}
+
+// Run seeds the frontier and blocks until workers have crawled everything in scope.
+// Cancelling ctx stops leasing; outstanding leases are waited for and the result is marked partial.
+func (co *Coordinator) Run(ctx context.Context) (Result, error) {
+	c := co.crawler
+	if c.pageChannel != nil {
+		defer close(c.pageChannel)
+	}
+
+	sites, err := c.newSites(ctx, nil, nil, c.streamFunc())
+	defer closeSites(sites)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// Discovered links go to the shared frontier instead of local goroutines
+	for i, cfg := range sites {
+		site := i
+		cfg.schedule = func(rawURL string, depth int) { co.push(site, rawURL, depth) }
+	}
+
+	co.mu.Lock()
+	co.ctx = ctx
+	co.sites = sites
+	co.mu.Unlock()
+
+	for i, cfg := range sites {
+		cfg.logf("starting crawl of: %s\n", c.seeds[i].URL)
+		co.push(i, c.seeds[i].URL, 0)
+	}
+
+	// Expire leases of dead workers even when nobody is asking for work
+	ticker := time.NewTicker(max(co.leaseTimeout/4, 10*time.Millisecond))
+	defer ticker.Stop()
+	cancelled := ctx.Done()
+	for {
+		co.mu.Lock()
+		co.expireLeasesLocked(time.Now())
+		co.checkDoneLocked()
+		co.mu.Unlock()
+
+		select {
+		case <-co.done:
+			return newResult(sites, ctx.Err() != nil), nil
+		case <-cancelled:
+			// Check once more right away, then only wait on the ticker and submits while leases drain
+			cancelled = nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// push admits a discovered URL and adds it to the frontier
+func (co *Coordinator) push(site int, rawURL string, depth int) {
+	_, normalizedURL, ok := co.sites[site].admit(rawURL)
+	if !ok {
+		return
+	}
+
+	co.mu.Lock()
+	defer co.mu.Unlock()
+	co.frontier = append(co.frontier, frontierItem{site: site, rawURL: rawURL, normalizedURL: normalizedURL, depth: depth})
+}
+
+// stoppedLocked reports whether Run's context has been cancelled
+func (co *Coordinator) stoppedLocked() bool {
+	return co.ctx != nil && co.ctx.Err() != nil
+}
+
+// budgetExhaustedLocked reports whether the run's time or download budget is used up; every site shares one budget
+func (co *Coordinator) budgetExhaustedLocked() bool {
+	return len(co.sites) > 0 && co.sites[0].budget.exhausted()
+}
+
+// expireLeasesLocked puts the URLs of timed-out leases back on the frontier
+func (co *Coordinator) expireLeasesLocked(now time.Time) {
+	for id, l := range co.leases {
+		if l.submitting || now.Before(l.expiresAt) {
+			continue
+		}
+		delete(co.leases, id)
+
+		// Once stopping, abandoned URLs are dropped rather than reassigned
+		if co.stoppedLocked() || co.budgetExhaustedLocked() {
+			continue
+		}
+		co.frontier = append(l.items, co.frontier...)
+		if len(co.sites) > 0 {
+			co.sites[0].logf("lease %s of worker %s expired, requeued %d URLs\n", id, l.workerID, len(l.items))
+		}
+	}
+}
+
+// checkDoneLocked finishes the crawl once nothing is queued or leased. A used-up budget drops the frontier,
+// as crawlPage skips queued pages locally, and the crawl finishes once outstanding leases come back.
+func (co *Coordinator) checkDoneLocked() {
+	if co.finished || co.sites == nil {
+		return
+	}
+	if len(co.frontier) > 0 && co.budgetExhaustedLocked() {
+		for _, item := range co.frontier {
+			co.sites[item.site].stats.recordSkip(skipRunBudget)
+		}
+		co.frontier = nil
+	}
+	if len(co.leases) > 0 {
+		return
+	}
+	if len(co.frontier) > 0 && !co.stoppedLocked() {
+		return
+	}
+	co.finished = true
+	close(co.done)
+	co.checkReleasedLocked()
+}
+
+// checkReleasedLocked closes released once the crawl is over and every known worker has heard so
+func (co *Coordinator) checkReleasedLocked() {
+	if !co.finished {
+		return
+	}
+	select {
+	case <-co.released:
+		return
+	default:
+	}
+	for _, told := range co.workers {
+		if !told {
+			return
+		}
+	}
+	close(co.released)
+}
+
+// WaitForWorkers blocks after Run until every worker that asked for work has been told the crawl is done,
+// so they can exit before the server is shut down. Workers that died never ask again, so bound ctx.
+func (co *Coordinator) WaitForWorkers(ctx context.Context) error {
+	select {
+	case <-co.released:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (co *Coordinator) handleLease(w http.ResponseWriter, r *http.Request) {
+	var req leaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid lease request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Max < 1 {
+		req.Max = defaultLeaseSize
+	}
+
+	co.mu.Lock()
+	co.expireLeasesLocked(time.Now())
+	co.checkDoneLocked()
+
+	resp := leaseResponse{URLs: []string{}, Done: co.finished}
+	if !co.finished && !co.stoppedLocked() && !co.budgetExhaustedLocked() && len(co.frontier) > 0 {
+		n := min(req.Max, len(co.frontier))
+		items := co.frontier[:n:n]
+		co.frontier = co.frontier[n:]
+
+		co.nextLeaseID++
+		resp.LeaseID = strconv.Itoa(co.nextLeaseID)
+		resp.ExpiresAt = time.Now().Add(co.leaseTimeout)
+		co.leases[resp.LeaseID] = &lease{workerID: req.WorkerID, items: items, expiresAt: resp.ExpiresAt}
+		for _, item := range items {
+			resp.URLs = append(resp.URLs, item.rawURL)
+		}
+	}
+	co.workers[req.WorkerID] = resp.Done
+	co.checkReleasedLocked()
+	co.mu.Unlock()
+
+	writeJSON(w, resp)
+}
+
+func (co *Coordinator) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	var req submitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid submit request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Late results for a reassigned lease are rejected, the new holder will report them
+	co.mu.Lock()
+	l, exists := co.leases[req.LeaseID]
+	if !exists || l.submitting {
+		co.mu.Unlock()
+		http.Error(w, "unknown or expired lease", http.StatusConflict)
+		return
+	}
+	l.submitting = true
+	co.mu.Unlock()
+
+	leased := make(map[string]frontierItem, len(l.items))
+	for _, item := range l.items {
+		leased[item.rawURL] = item
+	}
+
+	// Process pages before releasing the lease so their links are queued before we check for completion
+	for _, page := range req.Pages {
+		item, ok := leased[page.URL]
+		if !ok {
+			continue
+		}
+		page.Fetch.record(co.sites[item.site], nil)
+		co.sites[item.site].processPage(item.normalizedURL, item.depth, page.Page, page.Links)
+	}
+	for _, failed := range req.Failed {
+		item, ok := leased[failed.URL]
+		if !ok {
+			continue
+		}
+		cfg := co.sites[item.site]
+		failed.Fetch.record(cfg, errWorkerFetch)
+		cfg.stats.recordSkip(skipFetchFailed)
+		cfg.logf("worker %s failed to crawl %s\n", req.WorkerID, failed.URL)
+	}
+
+	co.mu.Lock()
+	delete(co.leases, req.LeaseID)
+	co.checkDoneLocked()
+	co.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (co *Coordinator) handleStatus(w http.ResponseWriter, r *http.Request) {
+	co.mu.Lock()
+	status := coordinatorStatus{
+		Frontier: len(co.frontier),
+		Leases:   len(co.leases),
+		Done:     co.finished,
+	}
+	sites := co.sites
+	co.mu.Unlock()
+
+	for _, cfg := range sites {
+		cfg.mu.Lock()
+		status.Pages += cfg.pageCount
+		cfg.mu.Unlock()
+	}
+
+	writeJSON(w, status)
+}
+
+// writeJSON sends v as a JSON response
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,313 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newLinkedSite serves pages that each link to the next few, so a crawl fans out
+func newLinkedSite(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><h1>Page %s</h1><a href="/a">a</a><a href="/b">b</a><a href="/c">c</a><a href="/tag/x">x</a></body></html>`, r.URL.Path)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCoordinatorWithWorkers(t *testing.T) {
+	site := newLinkedSite(t)
+
+	c, err := New([]Seed{{URL: site.URL}}, WithMaxConcurrency(2), WithMaxPages(10), WithExclude("/tag/"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	co := c.NewCoordinator(time.Minute)
+	server := httptest.NewServer(co)
+	defer server.Close()
+
+	// Several workers share the frontier
+	wg := &sync.WaitGroup{}
+	for i := range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.NewWorker(server.URL, fmt.Sprintf("worker-%d", i)).Run(context.Background()); err != nil {
+				t.Errorf("unexpected worker error: %v", err)
+			}
+		}()
+	}
+
+	result, err := co.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wg.Wait()
+
+	if result.Partial {
+		t.Error("expected a complete result")
+	}
+	pages := result.Sites[0].Pages
+	if len(pages) != 4 {
+		t.Errorf("expected 4 pages, got %d: %v", len(pages), pages)
+	}
+	if len(result.Sites[0].OutOfScope) != 1 {
+		t.Errorf("expected the tag page to be out of scope, got %v", result.Sites[0].OutOfScope)
+	}
+}
+
+func TestCoordinatorStats(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		time.Sleep(time.Millisecond)
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><h1>Page %s</h1><a href="/a">a</a><a href="/missing">missing</a></body></html>`, r.URL.Path)
+	}))
+	defer site.Close()
+
+	c, err := New([]Seed{{URL: site.URL}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	co := c.NewCoordinator(time.Minute)
+	server := httptest.NewServer(co)
+	defer server.Close()
+
+	go func() {
+		if err := c.NewWorker(server.URL, "worker").Run(context.Background()); err != nil {
+			t.Errorf("unexpected worker error: %v", err)
+		}
+	}()
+	result, err := co.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Requests made by workers show up in the coordinator's statistics
+	stats := result.Stats
+	if stats.Requests != 3 {
+		t.Errorf("expected 3 requests, got %d", stats.Requests)
+	}
+	if stats.StatusCodes[http.StatusOK] != 2 || stats.StatusCodes[http.StatusNotFound] != 1 {
+		t.Errorf("expected 2 OK and 1 not found, got %v", stats.StatusCodes)
+	}
+	if stats.Errors["http_4xx"] != 1 || stats.Skipped[skipFetchFailed] != 1 {
+		t.Errorf("expected the missing page counted as a failed fetch, got errors %v and skips %v", stats.Errors, stats.Skipped)
+	}
+	if stats.ContentTypes["text/html"] != 2 || stats.Bytes == 0 {
+		t.Errorf("expected 2 HTML pages and their bytes, got %v and %d bytes", stats.ContentTypes, stats.Bytes)
+	}
+	if stats.LatencyP50 <= 0 {
+		t.Errorf("expected worker latencies, got p50 %vms", stats.LatencyP50)
+	}
+}
+
+func TestCoordinatorBudget(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><h1>Page %s</h1>`, r.URL.Path)
+		if r.URL.Path == "/" {
+			for i := range 30 {
+				fmt.Fprintf(w, `<a href="/%d">%d</a>`, i, i)
+			}
+		} else {
+			fmt.Fprintf(w, `<p>%s</p>`, strings.Repeat("padding ", 40))
+		}
+		fmt.Fprint(w, `</body></html>`)
+	}))
+	defer site.Close()
+
+	c, err := New([]Seed{{URL: site.URL}}, WithMaxPages(100), WithMaxBytes(1000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	co := c.NewCoordinator(time.Minute)
+	server := httptest.NewServer(co)
+	defer server.Close()
+
+	go func() {
+		if err := c.NewWorker(server.URL, "worker").Run(context.Background()); err != nil {
+			t.Errorf("unexpected worker error: %v", err)
+		}
+	}()
+	result, err := co.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The seed and one batch fit in the budget; the rest of the frontier is dropped
+	if result.StopReason != StopMaxBytes {
+		t.Errorf("expected stop reason %q, got %q", StopMaxBytes, result.StopReason)
+	}
+	if result.Partial {
+		t.Error("expected a budget to wind the crawl down without marking it partial")
+	}
+	if pages := len(result.Sites[0].Pages); pages > 1+2*c.maxConcurrency {
+		t.Errorf("expected at most the seed and one batch of pages, got %d", pages)
+	}
+	if result.Stats.Skipped[skipRunBudget] == 0 {
+		t.Errorf("expected dropped URLs counted as run budget skips, got %v", result.Stats.Skipped)
+	}
+}
+
+func TestCoordinatorLeaseExpiry(t *testing.T) {
+	site := newLinkedSite(t)
+
+	c, err := New([]Seed{{URL: site.URL}}, WithMaxPages(10), WithExclude("/tag/"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	co := c.NewCoordinator(50 * time.Millisecond)
+	server := httptest.NewServer(co)
+	defer server.Close()
+
+	type runResult struct {
+		result Result
+		err    error
+	}
+	done := make(chan runResult, 1)
+	go func() {
+		result, err := co.Run(context.Background())
+		done <- runResult{result, err}
+	}()
+
+	// A worker leases the seed and dies without submitting
+	var lease leaseResponse
+	for len(lease.URLs) == 0 {
+		lease = postLease(t, server.URL, "dead-worker")
+	}
+
+	// Its lease expires and a live worker picks the seed up again
+	if err := c.NewWorker(server.URL, "live-worker").Run(context.Background()); err != nil {
+		t.Fatalf("unexpected worker error: %v", err)
+	}
+	run := <-done
+	if run.err != nil {
+		t.Fatalf("unexpected error: %v", run.err)
+	}
+	if len(run.result.Sites[0].Pages) != 4 {
+		t.Errorf("expected 4 pages, got %d", len(run.result.Sites[0].Pages))
+	}
+
+	// The dead worker's late results are rejected
+	payload, _ := json.Marshal(submitRequest{LeaseID: lease.LeaseID, WorkerID: "dead-worker"})
+	resp, err := http.Post(server.URL+"/submit", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected status %d for an expired lease, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	// The dead worker hasn't heard the crawl is done, so waiting for it times out until it asks again
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := co.WaitForWorkers(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the wait to time out, got %v", err)
+	}
+	if lease := postLease(t, server.URL, "dead-worker"); !lease.Done {
+		t.Errorf("expected the worker to be told the crawl is done, got %+v", lease)
+	}
+	if err := co.WaitForWorkers(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCoordinatorCancelled(t *testing.T) {
+	c, err := New([]Seed{{URL: "https://example.com"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	co := c.NewCoordinator(time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := co.Run(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Partial {
+		t.Error("expected a cancelled crawl to be partial")
+	}
+}
+
+func TestCoordinatorCancelledWaitsForLeases(t *testing.T) {
+	site := newLinkedSite(t)
+
+	c, err := New([]Seed{{URL: site.URL}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	co := c.NewCoordinator(time.Minute)
+	server := httptest.NewServer(co)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan Result, 1)
+	go func() {
+		result, _ := co.Run(ctx)
+		done <- result
+	}()
+
+	var lease leaseResponse
+	for len(lease.URLs) == 0 {
+		lease = postLease(t, server.URL, "worker")
+	}
+
+	// Cancelling stops leasing but the outstanding lease is still waited for
+	cancel()
+	select {
+	case <-done:
+		t.Fatal("expected Run to wait for the outstanding lease")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if lease := postLease(t, server.URL, "other-worker"); len(lease.URLs) != 0 {
+		t.Errorf("expected no more leases once cancelled, got %v", lease.URLs)
+	}
+
+	payload, _ := json.Marshal(submitRequest{LeaseID: lease.LeaseID, WorkerID: "worker"})
+	resp, err := http.Post(server.URL+"/submit", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	select {
+	case result := <-done:
+		if !result.Partial {
+			t.Error("expected a cancelled crawl to be partial")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected Run to return once the lease was submitted")
+	}
+}
+
+func postLease(t *testing.T, serverURL, workerID string) leaseResponse {
+	t.Helper()
+	payload, _ := json.Marshal(leaseRequest{WorkerID: workerID, Max: 1})
+	resp, err := http.Post(serverURL+"/lease", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var lease leaseResponse
+	if err := json.NewDecoder(resp.Body).Decode(&lease); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return lease
+}
@@ -0,0 +1,464 @@
+// Package crawler crawls websites concurrently and extracts structured page data.
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Crawler crawls one or more sites that share a single worker pool
+type Crawler struct {
+	seeds               []Seed
+	maxConcurrency      int
+	maxPages            int
+	maxDuration         time.Duration
+	maxBytes            int64
+	allowedHosts        []string
+	pathPrefixes        []string
+	includePatterns     []string
+	excludePatterns     []string
+	maxPerHost          int
+	adaptive            bool
+	normalization       URLNormalization
+	maxPathDepth        int
+	maxURLLength        int
+	maxRepeatedSegments int
+	maxQueryVariants    int
+	log                 io.Writer
+	hooks               hooks
+	pageHandler         func(PageResult)
+	pageChannel         chan<- PageResult
+	newVisitedSet       func(site string) (VisitedSet, error)
+	previous            map[string]map[string]PageData // Site -> normalized URL -> page, nil unless incremental
+	canonicalAliases    bool
+	skipNofollow        bool
+	maxRecords          int
+}
+
+// Option configures a Crawler
+type Option func(*Crawler)
+
+// WithMaxConcurrency sets how many pages are fetched at once across all sites (default 3)
+func WithMaxConcurrency(n int) Option {
+	return func(c *Crawler) { c.maxConcurrency = n }
+}
+
+// WithMaxPages sets the page budget for seeds that don't have their own (default 10)
+func WithMaxPages(n int) Option {
+	return func(c *Crawler) { c.maxPages = n }
+}
+
+// WithMaxDuration stops starting new pages once the crawl has run for d (default 0, no limit)
+func WithMaxDuration(d time.Duration) Option {
+	return func(c *Crawler) { c.maxDuration = d }
+}
+
+// WithMaxBytes stops starting new pages once n bytes of page content have been downloaded (default 0, no limit)
+func WithMaxBytes(n int64) Option {
+	return func(c *Crawler) { c.maxBytes = n }
+}
+
+// WithAllowedHosts sets the hosts in scope, where "*.example.com" matches the domain and its subdomains.
+// By default each site only crawls its seed's host.
+func WithAllowedHosts(hosts ...string) Option {
+	return func(c *Crawler) { c.allowedHosts = append(c.allowedHosts, hosts...) }
+}
+
+// WithPathPrefixes limits the crawl to URL paths starting with one of the prefixes. Seeds are crawled
+// even outside them, so a home page seed can lead into the prefixes.
+func WithPathPrefixes(prefixes ...string) Option {
+	return func(c *Crawler) { c.pathPrefixes = append(c.pathPrefixes, prefixes...) }
+}
+
+// WithInclude limits the crawl to URLs matching at least one of the regular expressions
+func WithInclude(patterns ...string) Option {
+	return func(c *Crawler) { c.includePatterns = append(c.includePatterns, patterns...) }
+}
+
+// WithExclude skips URLs matching any of the regular expressions
+func WithExclude(patterns ...string) Option {
+	return func(c *Crawler) { c.excludePatterns = append(c.excludePatterns, patterns...) }
+}
+
+// WithMaxPerHost caps concurrent requests to any one host within a site (default 0, no limit)
+func WithMaxPerHost(n int) Option {
+	return func(c *Crawler) { c.maxPerHost = n }
+}
+
+// WithAdaptiveConcurrency adjusts each host's concurrency to how it responds: it ramps up while
+// latency stays stable and backs off on 429, 503, failures or rising latency. maxConcurrency, or
+// the per-host cap when set, stays the ceiling.
+func WithAdaptiveConcurrency(enabled bool) Option {
+	return func(c *Crawler) { c.adaptive = enabled }
+}
+
+// WithURLNormalization sets how URLs are reduced to the keys that identify pages.
+// By default query parameters are part of a page's identity, sorted by name.
+func WithURLNormalization(n URLNormalization) Option {
+	return func(c *Crawler) { c.normalization = n }
+}
+
+// WithCanonicalAliases treats a page whose rel=canonical names another in-scope page as an alias:
+// the canonical page is crawled in its place and the alias is only listed in SiteResult.Canonicals
+func WithCanonicalAliases(enabled bool) Option {
+	return func(c *Crawler) { c.canonicalAliases = enabled }
+}
+
+// WithSkipNofollow stops the crawler from following links marked rel="nofollow". A URL that
+// the same page also links to without nofollow is still followed.
+func WithSkipNofollow(enabled bool) Option {
+	return func(c *Crawler) { c.skipNofollow = enabled }
+}
+
+// DefaultStreamingMaxRecords caps each per-URL record in streaming mode when WithMaxRecords isn't set
+const DefaultStreamingMaxRecords = 100000
+
+// WithMaxRecords caps each per-URL record a site keeps for its reports and bookkeeping (out-of-scope URLs,
+// stripped parameters, canonicals and canonical aliases, changes since the previous crawl, trap patterns and
+// query variants, and the content hashes used to spot duplicates) at n entries. Without a cap these grow with
+// every URL discovered, so the default is no limit (0) when pages are kept in memory and
+// DefaultStreamingMaxRecords in streaming mode; a negative n removes the cap in both. Once a record is full,
+// new entries are dropped and counted in SiteResult.RecordsDropped; a page whose content hash is dropped can't
+// be matched by later duplicates, and a path whose query variants aren't tracked can't be flagged as a trap.
+func WithMaxRecords(n int) Option {
+	return func(c *Crawler) { c.maxRecords = n }
+}
+
+// WithTrapLimits sets the crawler trap heuristics, where 0 disables a check
+func WithTrapLimits(maxPathDepth, maxURLLength, maxRepeatedSegments, maxQueryVariants int) Option {
+	return func(c *Crawler) {
+		c.maxPathDepth = maxPathDepth
+		c.maxURLLength = maxURLLength
+		c.maxRepeatedSegments = maxRepeatedSegments
+		c.maxQueryVariants = maxQueryVariants
+	}
+}
+
+// WithLogOutput writes crawl progress to w (default: discarded)
+func WithLogOutput(w io.Writer) Option {
+	return func(c *Crawler) { c.log = w }
+}
+
+// WithOnRequest adds a hook that may modify each outgoing request
+func WithOnRequest(hook RequestHook) Option {
+	return func(c *Crawler) { c.hooks.onRequest = append(c.hooks.onRequest, hook) }
+}
+
+// WithOnResponse adds a hook that inspects each response before its body is read
+func WithOnResponse(hook ResponseHook) Option {
+	return func(c *Crawler) { c.hooks.onResponse = append(c.hooks.onResponse, hook) }
+}
+
+// WithOnPage adds a hook that may modify or drop each page's extracted data.
+// Links on a dropped page are still followed.
+func WithOnPage(hook PageHook) Option {
+	return func(c *Crawler) { c.hooks.onPage = append(c.hooks.onPage, hook) }
+}
+
+// WithOnLink adds a hook that accepts or rejects each discovered link before it is queued
+func WithOnLink(hook LinkHook) Option {
+	return func(c *Crawler) { c.hooks.onLink = append(c.hooks.onLink, hook) }
+}
+
+// WithPageHandler switches the crawler to streaming mode: each page is passed to fn as soon as it
+// is processed instead of being kept in the Result. Calls to fn are never concurrent.
+func WithPageHandler(fn func(PageResult)) Option {
+	return func(c *Crawler) { c.pageHandler = fn }
+}
+
+// WithPageChannel switches the crawler to streaming mode, sending each page on ch as soon as it is
+// processed. The crawl blocks while ch is full, and Run closes ch when it returns.
+func WithPageChannel(ch chan<- PageResult) Option {
+	return func(c *Crawler) { c.pageChannel = ch }
+}
+
+// WithVisitedSet sets how each site's visited set is created (default: NewMemoryVisitedSet).
+// site is the SiteResult.Site key; Run closes every set it creates when the crawl ends.
+func WithVisitedSet(newSet func(site string) (VisitedSet, error)) Option {
+	return func(c *Crawler) { c.newVisitedSet = newSet }
+}
+
+// WithPreviousResults makes the crawl incremental: pages from a previous crawl, as read by ReadState,
+// are carried over without a download when the site's sitemap or a conditional request says they are unchanged
+func WithPreviousResults(pages []PageResult) Option {
+	return func(c *Crawler) {
+		if c.previous == nil {
+			c.previous = make(map[string]map[string]PageData)
+		}
+		for _, page := range pages {
+			if c.previous[page.Site] == nil {
+				c.previous[page.Site] = make(map[string]PageData)
+			}
+			c.previous[page.Site][page.NormalizedURL] = page.Page
+		}
+	}
+}
+
+// New creates a Crawler for the given seeds
+func New(seeds []Seed, opts ...Option) (*Crawler, error) {
+	c := &Crawler{
+		seeds:               seeds,
+		maxConcurrency:      3,
+		maxPages:            10,
+		maxPathDepth:        DefaultMaxPathDepth,
+		maxURLLength:        DefaultMaxURLLength,
+		maxRepeatedSegments: DefaultMaxRepeatedSegments,
+		maxQueryVariants:    DefaultMaxQueryVariants,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if len(c.seeds) == 0 {
+		return nil, errors.New("no seed URLs provided")
+	}
+	if c.maxConcurrency < 1 {
+		return nil, errors.New("maxConcurrency must be at least 1")
+	}
+	if c.maxPages < 1 {
+		return nil, errors.New("maxPages must be at least 1")
+	}
+	if c.pageHandler != nil && c.pageChannel != nil {
+		return nil, errors.New("use either a page handler or a page channel, not both")
+	}
+	// Sites are keyed by seed, and the key names their files, so two seeds must not share one
+	seen := make(map[string]string, len(c.seeds))
+	for _, s := range c.seeds {
+		seedURL, err := url.Parse(s.URL)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing seed URL: %w", err)
+		}
+		filename := SiteFilename(siteKey(seedURL))
+		if other, exists := seen[filename]; exists {
+			return nil, fmt.Errorf("seeds %s and %s are the same site", other, s.URL)
+		}
+		seen[filename] = s.URL
+	}
+
+	return c, nil
+}
+
+// PageResult is one completed page, as delivered in streaming mode
+type PageResult struct {
+	Site          string   `json:"site"` // Key of the seed the page was found from, see SiteResult.Site
+	NormalizedURL string   `json:"normalized_url"`
+	Page          PageData `json:"page"`
+}
+
+// Result is everything gathered by a crawl
+type Result struct {
+	Sites      []SiteResult
+	Partial    bool   // The crawl was cancelled before it finished
+	StopReason string // StopMaxPages, StopMaxDuration, StopMaxBytes or StopCancelled, empty if nothing was left to crawl
+	Stats      Stats  // Statistics for the whole run
+}
+
+// SiteResult is everything gathered for one seed
+type SiteResult struct {
+	Site       string                   // Seed host, plus its path and query unless the seed is the host's root
+	MaxPages   int                      // Page budget the site was crawled with
+	PageCount  int                      // Pages crawled, including streamed pages
+	Pages      map[string]PageData      // Normalized URL -> page data, empty when streaming
+	OutOfScope map[string]string        // URL -> scope rule that excluded it
+	Traps      map[string]TrapRecord    // URL pattern -> why it was cut off
+	Changes    map[string]string        // Normalized URL -> ChangeNew, ChangeChanged or ChangeRemoved, nil unless incremental
+	Unchanged  int                      // Pages that match the previous crawl
+	Stripped   map[string]StrippedURL   // Discovered URL -> URL queued without tracking parameters
+	Canonicals map[string]CanonicalLink // Page URL -> canonical URL it declares, when that is another page
+
+	RecordsDropped int // Record entries left out because of WithMaxRecords
+}
+
+// Run crawls every seed until the page budgets are used up or there is nothing left to crawl.
+// Cancelling ctx stops new pages from starting; pages in flight finish and the result is marked partial.
+func (c *Crawler) Run(ctx context.Context) (Result, error) {
+	// Every site shares one worker pool and wait group
+	concurrencyControl := make(chan struct{}, c.maxConcurrency)
+	wg := &sync.WaitGroup{}
+
+	if c.pageChannel != nil {
+		defer close(c.pageChannel)
+	}
+
+	sites, err := c.newSites(ctx, concurrencyControl, wg, c.streamFunc())
+	defer closeSites(sites)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// Incremental crawls use the sitemap to skip pages that haven't changed
+	for _, cfg := range sites {
+		if cfg.previous != nil {
+			cfg.loadSitemap()
+		}
+	}
+
+	// wg.Add before spawning goroutine
+	for i, cfg := range sites {
+		cfg.logf("starting crawl of: %s\n", c.seeds[i].URL)
+		wg.Add(1)
+		go cfg.crawlPage(c.seeds[i].URL, 0)
+	}
+	wg.Wait()
+
+	return newResult(sites, ctx.Err() != nil), nil
+}
+
+// streamFunc hands pages to the streaming consumer one at a time, or returns nil when not streaming
+func (c *Crawler) streamFunc() func(PageResult) {
+	if c.pageChannel != nil {
+		return func(page PageResult) { c.pageChannel <- page }
+	}
+	if c.pageHandler != nil {
+		streamMu := &sync.Mutex{}
+		return func(page PageResult) {
+			streamMu.Lock()
+			defer streamMu.Unlock()
+			c.pageHandler(page)
+		}
+	}
+	return nil
+}
+
+// newSites builds the per-site crawl state for every seed
+func (c *Crawler) newSites(ctx context.Context, concurrencyControl chan struct{}, wg *sync.WaitGroup, stream func(PageResult)) ([]*config, error) {
+	sites := []*config{}
+	budget := newCrawlBudget(c.maxDuration, c.maxBytes)
+	stats := newStatsCollector()
+	for _, s := range c.seeds {
+		baseURL, err := url.Parse(s.URL)
+		if err != nil {
+			return sites, fmt.Errorf("error parsing seed URL: %w", err)
+		}
+
+		scope, err := newScopeRules(baseURL, c.allowedHosts, c.pathPrefixes, c.includePatterns, c.excludePatterns)
+		if err != nil {
+			return sites, fmt.Errorf("error parsing scope rules: %w", err)
+		}
+
+		maxPages := c.maxPages
+		if s.MaxPages > 0 {
+			maxPages = s.MaxPages
+		}
+
+		adaptiveCeiling := 0
+		if c.adaptive {
+			adaptiveCeiling = c.maxConcurrency
+			if c.maxPerHost > 0 {
+				adaptiveCeiling = min(c.maxPerHost, c.maxConcurrency)
+			}
+		}
+
+		// Streaming crawls keep nothing per page, so their records are capped by default too
+		maxRecords := c.maxRecords
+		if maxRecords == 0 && stream != nil {
+			maxRecords = DefaultStreamingMaxRecords
+		}
+		traps := newTrapDetector(c.maxPathDepth, c.maxURLLength, c.maxRepeatedSegments, c.maxQueryVariants)
+		traps.maxRecords = maxRecords
+
+		site := siteKey(baseURL)
+		visited := NewMemoryVisitedSet()
+		if c.newVisitedSet != nil {
+			visited, err = c.newVisitedSet(site)
+			if err != nil {
+				return sites, fmt.Errorf("error creating visited set for %s: %w", site, err)
+			}
+		}
+
+		// A site missing from the previous crawl is still incremental, every page is new
+		var previous map[string]PageData
+		if c.previous != nil {
+			previous = c.previous[site]
+			if previous == nil {
+				previous = make(map[string]PageData)
+			}
+		}
+
+		sites = append(sites, &config{
+			pages:              make(map[string]PageData),
+			visited:            visited,
+			baseURL:            baseURL,
+			mu:                 &sync.Mutex{},
+			concurrencyControl: concurrencyControl,
+			wg:                 wg,
+			maxPages:           maxPages,
+			ctx:                ctx,
+			scope:              scope,
+			outOfScope:         make(map[string]string),
+			site:               site,
+			maxPerHost:         c.maxPerHost,
+			adaptiveCeiling:    adaptiveCeiling,
+			traps:              traps,
+			log:                c.log,
+			hooks:              &c.hooks,
+			stream:             stream,
+			previous:           previous,
+			budget:             budget,
+			stats:              stats,
+			normalization:      c.normalization,
+			canonicalAliases:   c.canonicalAliases,
+			skipNofollow:       c.skipNofollow,
+			maxRecords:         maxRecords,
+		})
+	}
+	return sites, nil
+}
+
+// closeSites releases every site's visited set
+func closeSites(sites []*config) {
+	for _, cfg := range sites {
+		if err := cfg.visited.Close(); err != nil {
+			cfg.logf("error closing visited set for %s: %v\n", cfg.site, err)
+		}
+	}
+}
+
+// newResult collects what every site gathered
+func newResult(sites []*config, partial bool) Result {
+	result := Result{Partial: partial}
+	pageBudgetsUsed := len(sites) > 0
+	totalPages := 0
+	for _, cfg := range sites {
+		if cfg.visitedSet().Len() < cfg.maxPages {
+			pageBudgetsUsed = false
+		}
+
+		cfg.mu.Lock()
+		totalPages += cfg.pageCount
+		result.Sites = append(result.Sites, SiteResult{
+			Site:       cfg.site,
+			MaxPages:   cfg.maxPages,
+			PageCount:  cfg.pageCount,
+			Pages:      cfg.pages,
+			OutOfScope: cfg.outOfScope,
+			Traps:      cfg.traps.trappedPatterns(),
+			Changes:    cfg.siteChanges(partial),
+			Unchanged:  cfg.unchanged,
+			Stripped:   cfg.stripped,
+			Canonicals: cfg.canonicals,
+
+			RecordsDropped: cfg.recordsDropped + cfg.traps.droppedRecords(),
+		})
+		cfg.mu.Unlock()
+	}
+
+	switch {
+	case partial:
+		result.StopReason = StopCancelled
+	case len(sites) > 0 && sites[0].budget.stopReason() != "":
+		result.StopReason = sites[0].budget.stopReason()
+	case pageBudgetsUsed:
+		result.StopReason = StopMaxPages
+	}
+
+	if len(sites) > 0 {
+		result.Stats = sites[0].stats.snapshot(totalPages, result.StopReason)
+	}
+	return result
+}
@@ -0,0 +1,427 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewValidation(t *testing.T) {
+	seeds := []Seed{{URL: "https://example.com"}}
+
+	if _, err := New(nil); err == nil {
+		t.Error("expected error without seeds")
+	}
+	if _, err := New(seeds, WithMaxConcurrency(0)); err == nil {
+		t.Error("expected error for maxConcurrency 0")
+	}
+	if _, err := New(seeds, WithMaxPages(0)); err == nil {
+		t.Error("expected error for maxPages 0")
+	}
+	if _, err := New([]Seed{{URL: "://bad"}}); err == nil {
+		t.Error("expected error for an invalid seed URL")
+	}
+	if _, err := New([]Seed{{URL: "https://example.com/blog"}, {URL: "http://example.com/blog/"}}); err == nil {
+		t.Error("expected error for two seeds of the same site")
+	}
+	if _, err := New(seeds, WithMaxConcurrency(2), WithMaxPages(5)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCrawlerRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><h1>Page %s</h1><a href="/one">1</a><a href="/two">2</a><a href="/tag/x">x</a></body></html>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	c, err := New([]Seed{{URL: server.URL}}, WithMaxConcurrency(2), WithMaxPages(10), WithExclude("/tag/"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Partial {
+		t.Error("expected a complete result")
+	}
+	if len(result.Sites) != 1 {
+		t.Fatalf("expected 1 site, got %d", len(result.Sites))
+	}
+
+	site := result.Sites[0]
+	if len(site.Pages) != 3 {
+		t.Errorf("expected 3 pages, got %d", len(site.Pages))
+	}
+	if len(site.OutOfScope) != 1 {
+		t.Errorf("expected the tag page to be out of scope, got %v", site.OutOfScope)
+	}
+}
+
+func TestCrawlerRunCancelled(t *testing.T) {
+	c, err := New([]Seed{{URL: "https://example.com"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := c.Run(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Partial {
+		t.Error("expected a cancelled crawl to be partial")
+	}
+}
+
+func TestCrawlerRunStreaming(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><h1>Page %s</h1><a href="/one">1</a><a href="/two">2</a></body></html>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	streamed := []PageResult{}
+	c, err := New([]Seed{{URL: server.URL}}, WithPageHandler(func(page PageResult) {
+		streamed = append(streamed, page)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Pages are handed to the handler instead of being kept in the result
+	if len(streamed) != 3 {
+		t.Errorf("expected 3 streamed pages, got %d", len(streamed))
+	}
+	site := result.Sites[0]
+	if len(site.Pages) != 0 {
+		t.Errorf("expected no pages kept in memory, got %d", len(site.Pages))
+	}
+	if site.PageCount != 3 {
+		t.Errorf("expected PageCount 3, got %d", site.PageCount)
+	}
+}
+
+func TestCrawlerRunPageChannel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><h1>Home</h1><a href="/about">About</a></body></html>`)
+	}))
+	defer server.Close()
+
+	pages := make(chan PageResult)
+	c, err := New([]Seed{{URL: server.URL}}, WithPageChannel(pages))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := c.Run(context.Background())
+		done <- err
+	}()
+
+	// Run closes the channel once the crawl is over
+	count := 0
+	for page := range pages {
+		if page.Site == "" || page.Page.URL == "" {
+			t.Errorf("unexpected page %+v", page)
+		}
+		count++
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 pages on the channel, got %d", count)
+	}
+}
+
+func TestCrawlerRunAdaptiveConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><h1>Page %s</h1><a href="/one">1</a><a href="/two">2</a><a href="/three">3</a></body></html>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	c, err := New([]Seed{{URL: server.URL}}, WithMaxConcurrency(8), WithMaxPerHost(2), WithAdaptiveConcurrency(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Sites[0].Pages) != 4 {
+		t.Errorf("expected 4 pages, got %d", len(result.Sites[0].Pages))
+	}
+	if peak.Load() > 2 {
+		t.Errorf("expected at most 2 concurrent requests, got %d", peak.Load())
+	}
+}
+
+func TestCrawlerRunBudgets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><h1>Page %s</h1><a href="/one">1</a><a href="/two">2</a><a href="/three">3</a></body></html>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		reason   string
+		maxPages int
+	}{
+		{"no budget", nil, "", 4},
+		{"page budget", []Option{WithMaxPages(2)}, StopMaxPages, 2},
+		{"byte budget", []Option{WithMaxBytes(1)}, StopMaxBytes, 1},
+		{"time budget", []Option{WithMaxDuration(time.Nanosecond)}, StopMaxDuration, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := New([]Seed{{URL: server.URL}}, append([]Option{WithMaxConcurrency(1)}, tc.opts...)...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			result, err := c.Run(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Partial {
+				t.Error("expected a budget to wind the crawl down without marking it partial")
+			}
+			if result.StopReason != tc.reason {
+				t.Errorf("expected stop reason %q, got %q", tc.reason, result.StopReason)
+			}
+			if pages := len(result.Sites[0].Pages); pages > tc.maxPages {
+				t.Errorf("expected at most %d pages, got %d", tc.maxPages, pages)
+			}
+		})
+	}
+}
+
+func TestCrawlerRunPaginatedListing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		page := r.URL.Query().Get("page")
+		fmt.Fprintf(w, `<html><body><h1>Listing %s</h1><a href="/list?page=1">1</a><a href="/list?page=2">2</a><a href="/list?page=3">3</a></body></html>`, page)
+	}))
+	defer server.Close()
+
+	// Each page of the listing is its own page by default, and one page when queries are ignored
+	tests := []struct {
+		name          string
+		normalization URLNormalization
+		expected      int
+	}{
+		{"queries kept", URLNormalization{}, 4},
+		{"queries ignored", URLNormalization{IgnoreQuery: true}, 2},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := New([]Seed{{URL: server.URL}}, WithURLNormalization(tc.normalization))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			result, err := c.Run(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(result.Sites[0].Pages) != tc.expected {
+				t.Errorf("expected %d pages, got %d", tc.expected, len(result.Sites[0].Pages))
+			}
+		})
+	}
+}
+
+func TestCrawlerRunStripsTrackingParams(t *testing.T) {
+	mu := &sync.Mutex{}
+	queries := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.RawQuery)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><h1>Page %s</h1><a href="/post?id=1&utm_source=feed">1</a><a href="/post?id=1&sessionid=abc">1</a></body></html>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	c, err := New([]Seed{{URL: server.URL}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	site := result.Sites[0]
+	if len(site.Pages) != 2 {
+		t.Errorf("expected both links to be one page, got %d pages", len(site.Pages))
+	}
+	for _, query := range queries {
+		if query != "" && query != "id=1" {
+			t.Errorf("expected tracking parameters to be stripped before fetching, got %q", query)
+		}
+	}
+	stripped := site.Stripped[server.URL+"/post?id=1&utm_source=feed"]
+	if stripped.URL != server.URL+"/post?id=1" || !reflect.DeepEqual(stripped.Params, []string{"utm_source"}) {
+		t.Errorf("expected utm_source stripped, got %+v", stripped)
+	}
+}
+
+func TestCrawlerRunSameHostSeeds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		section := "/" + strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")[0]
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><h1>Page %s</h1><a href="%s/one">1</a><a href="%s/two">2</a></body></html>`, r.URL.Path, section, section)
+	}))
+	defer server.Close()
+
+	// Each seed gets its own disk visited set, named after its site
+	dir := t.TempDir()
+	newVisitedSet := func(site string) (VisitedSet, error) {
+		return NewDiskVisitedSet(filepath.Join(dir, "visited_"+SiteFilename(site)+".db"))
+	}
+	c, err := New([]Seed{{URL: server.URL + "/blog"}, {URL: server.URL + "/docs"}}, WithVisitedSet(newVisitedSet))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Sites) != 2 {
+		t.Fatalf("expected 2 sites, got %d", len(result.Sites))
+	}
+	host := strings.TrimPrefix(server.URL, "http://")
+	for i, section := range []string{"/blog", "/docs"} {
+		site := result.Sites[i]
+		if site.Site != host+section {
+			t.Errorf("expected site %q, got %q", host+section, site.Site)
+		}
+		if len(site.Pages) != 3 {
+			t.Errorf("expected 3 pages for %s, got %d: %v", site.Site, len(site.Pages), site.Pages)
+		}
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.db")); len(files) != 2 {
+		t.Errorf("expected one visited set file per site, got %v", files)
+	}
+}
+
+func TestCrawlerRunMaxRecords(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><h1>Home</h1>`)
+		for i := range 5 {
+			fmt.Fprintf(w, `<a href="https://other-%d.example/">%d</a>`, i, i)
+		}
+		fmt.Fprint(w, `</body></html>`)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		maxRecords int
+		outOfScope int
+		dropped    int
+	}{
+		{"no limit", 0, 5, 0},
+		{"limited", 2, 2, 3},
+		{"negative is no limit", -1, 5, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := New([]Seed{{URL: server.URL}}, WithMaxRecords(tc.maxRecords))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			result, err := c.Run(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			site := result.Sites[0]
+			if len(site.OutOfScope) != tc.outOfScope {
+				t.Errorf("expected %d out-of-scope URLs, got %d", tc.outOfScope, len(site.OutOfScope))
+			}
+			if site.RecordsDropped != tc.dropped {
+				t.Errorf("expected %d dropped records, got %d", tc.dropped, site.RecordsDropped)
+			}
+			// Skip counts still cover every URL
+			if result.Stats.Skipped[skipOutOfScope] != 5 {
+				t.Errorf("expected 5 out-of-scope skips, got %d", result.Stats.Skipped[skipOutOfScope])
+			}
+		})
+	}
+}
+
+func TestNewSitesStreamingMaxRecords(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRecords int
+		stream     func(PageResult)
+		expected   int
+	}{
+		{"in memory", 0, nil, 0},
+		{"streaming default", 0, func(PageResult) {}, DefaultStreamingMaxRecords},
+		{"streaming with a limit", 10, func(PageResult) {}, 10},
+		{"streaming without a limit", -1, func(PageResult) {}, -1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := New([]Seed{{URL: "https://example.com"}}, WithMaxRecords(tc.maxRecords))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			sites, err := c.newSites(context.Background(), make(chan struct{}, 1), &sync.WaitGroup{}, tc.stream)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer closeSites(sites)
+
+			if sites[0].maxRecords != tc.expected {
+				t.Errorf("expected a record cap of %d, got %d", tc.expected, sites[0].maxRecords)
+			}
+			if sites[0].traps.maxRecords != tc.expected {
+				t.Errorf("expected a trap record cap of %d, got %d", tc.expected, sites[0].traps.maxRecords)
+			}
+		})
+	}
+}
@@ -0,0 +1,642 @@
+package crawler
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ReportOptions controls which report files WriteReports produces
+type ReportOptions struct {
+	PerSite               bool // One set of reports per site instead of combined reports
+	NearDuplicateDistance int  // Largest SimHash distance grouped as near-duplicate
+	Streamed              bool // Pages were streamed, so skip the page and duplicate reports
+	JSONLines             bool // Also write every page with its full link records as JSON Lines, e.g. report.jsonl
+}
+
+// WriteReports writes the page report to filename and every report section next to it.
+// Filenames are marked as partial when the result is.
+func WriteReports(result Result, filename string, opts ReportOptions) error {
+	reportName := func(name string) string {
+		if result.Partial {
+			return PartialReportFilename(name)
+		}
+		return name
+	}
+
+	// Statistics cover the whole run, however the pages are split up
+	if err := writeStatsReport(result.Stats, reportName(statsReportFilename(filename))); err != nil {
+		return err
+	}
+
+	if opts.PerSite {
+		for _, site := range result.Sites {
+			siteFilename := sectionReportFilename(filename, SiteFilename(site.Site))
+			if !opts.Streamed {
+				if err := writeCSVReport(site.Pages, reportName(siteFilename)); err != nil {
+					return err
+				}
+			}
+			if opts.JSONLines && !opts.Streamed {
+				if err := writeJSONLinesReport([]SiteResult{site}, reportName(jsonLinesReportFilename(siteFilename))); err != nil {
+					return err
+				}
+			}
+			if err := writeReportSections(site, siteFilename, reportName, opts); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch {
+	case opts.Streamed:
+		// The page report was written as pages arrived
+	case len(result.Sites) == 1:
+		// A single site keeps the original report layout
+		if err := writeCSVReport(result.Sites[0].Pages, reportName(filename)); err != nil {
+			return err
+		}
+	default:
+		if err := writeCombinedCSVReport(result.Sites, reportName(filename)); err != nil {
+			return err
+		}
+	}
+
+	if opts.JSONLines && !opts.Streamed {
+		if err := writeJSONLinesReport(result.Sites, reportName(jsonLinesReportFilename(filename))); err != nil {
+			return err
+		}
+	}
+
+	// Merge every site so each section is a single file
+	merged := SiteResult{
+		Pages:      make(map[string]PageData),
+		OutOfScope: make(map[string]string),
+		Traps:      make(map[string]TrapRecord),
+		Stripped:   make(map[string]StrippedURL),
+		Canonicals: make(map[string]CanonicalLink),
+	}
+	for _, site := range result.Sites {
+		for normalizedURL, pageData := range site.Pages {
+			merged.Pages[normalizedURL] = pageData
+		}
+		for rawURL, rule := range site.OutOfScope {
+			if _, exists := merged.OutOfScope[rawURL]; !exists {
+				merged.OutOfScope[rawURL] = rule
+			}
+		}
+		for pattern, record := range site.Traps {
+			merged.Traps[pattern] = record
+		}
+		for rawURL, stripped := range site.Stripped {
+			merged.Stripped[rawURL] = stripped
+		}
+		for pageURL, canonical := range site.Canonicals {
+			merged.Canonicals[pageURL] = canonical
+		}
+		if site.Changes != nil && merged.Changes == nil {
+			merged.Changes = make(map[string]string)
+		}
+		for normalizedURL, change := range site.Changes {
+			merged.Changes[normalizedURL] = change
+		}
+	}
+	return writeReportSections(merged, filename, reportName, opts)
+}
+
+// writeReportSections writes every extra report section for a site next to its page report
+func writeReportSections(site SiteResult, filename string, reportName func(string) string, opts ReportOptions) error {
+	if err := writeOutOfScopeReport(site.OutOfScope, reportName(sectionReportFilename(filename, "out_of_scope"))); err != nil {
+		return err
+	}
+	if err := writeTrapsReport(site.Traps, reportName(sectionReportFilename(filename, "traps"))); err != nil {
+		return err
+	}
+
+	if err := writeStrippedParamsReport(site.Stripped, reportName(sectionReportFilename(filename, "stripped_params"))); err != nil {
+		return err
+	}
+
+	if err := writeCanonicalsReport(site.Canonicals, reportName(sectionReportFilename(filename, "canonicals"))); err != nil {
+		return err
+	}
+
+	// Only incremental crawls know what changed
+	if site.Changes != nil {
+		if err := writeChangesReport(site.Changes, reportName(sectionReportFilename(filename, "changes"))); err != nil {
+			return err
+		}
+	}
+
+	// Contact, link, resource and duplicate reports need every page in memory
+	if opts.Streamed {
+		return nil
+	}
+	if err := writeContactsReport(site.Pages, reportName(sectionReportFilename(filename, "contacts"))); err != nil {
+		return err
+	}
+	if err := writeUncrawledLinksReport(site.Pages, reportName(sectionReportFilename(filename, "uncrawled_links"))); err != nil {
+		return err
+	}
+	if err := writeResourcesReport(site.Pages, reportName(sectionReportFilename(filename, "resources"))); err != nil {
+		return err
+	}
+	if err := writeDuplicatesReport(site.Pages, reportName(sectionReportFilename(filename, "duplicates"))); err != nil {
+		return err
+	}
+	return writeNearDuplicatesReport(site.Pages, opts.NearDuplicateDistance, reportName(sectionReportFilename(filename, "near_duplicates")))
+}
+
+// jsonLinesReportFilename derives the JSON Lines page report filename, e.g. report.csv -> report.jsonl
+func jsonLinesReportFilename(filename string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + ".jsonl"
+}
+
+// writeJSONLinesReport exports every page with its full records, one PageResult per line
+func writeJSONLinesReport(sites []SiteResult, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := WriteState(file, Result{Sites: sites}); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// PartialReportFilename marks a report filename as partial, e.g. report.csv -> report.partial.csv
+func PartialReportFilename(filename string) string {
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + ".partial" + ext
+}
+
+// sectionReportFilename derives the filename of an extra report section, e.g. report.csv -> report_out_of_scope.csv
+func sectionReportFilename(filename, section string) string {
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "_" + section + ext
+}
+
+// writeCSVReport exports crawl results to a CSV file
+func writeCSVReport(pages map[string]PageData, filename string) error {
+	// Create the CSV file
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Create CSV writer
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	// Write header row
+	headers := []string{"page_url", "h1", "first_paragraph", "outgoing_link_urls", "image_urls", "duplicate_of", "normalized_url", "canonical_url"}
+	if err := writer.Write(headers); err != nil {
+		return err
+	}
+
+	// Write data rows
+	for normalizedURL, pageData := range pages {
+		if err := writer.Write(pageDataRow(normalizedURL, pageData)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CSVPageWriter writes pages to a CSV report as they arrive, for streaming crawls
+type CSVPageWriter struct {
+	file     *os.File
+	writer   *csv.Writer
+	withSite bool
+}
+
+// NewCSVPageWriter creates a streaming page report, with a leading site column if withSite is set
+func NewCSVPageWriter(filename string, withSite bool) (*CSVPageWriter, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &CSVPageWriter{file: file, writer: csv.NewWriter(file), withSite: withSite}
+	headers := []string{"page_url", "h1", "first_paragraph", "outgoing_link_urls", "image_urls", "duplicate_of", "normalized_url", "canonical_url"}
+	if withSite {
+		headers = append([]string{"site"}, headers...)
+	}
+	if err := w.writer.Write(headers); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Write appends one page and flushes it to disk
+func (w *CSVPageWriter) Write(page PageResult) error {
+	row := pageDataRow(page.NormalizedURL, page.Page)
+	if w.withSite {
+		row = append([]string{page.Site}, row...)
+	}
+	if err := w.writer.Write(row); err != nil {
+		return err
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// Close flushes and closes the report
+func (w *CSVPageWriter) Close() error {
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// writeCombinedCSVReport exports the pages of several sites to one CSV file with a site column
+func writeCombinedCSVReport(sites []SiteResult, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	headers := []string{"site", "page_url", "h1", "first_paragraph", "outgoing_link_urls", "image_urls", "duplicate_of", "normalized_url", "canonical_url"}
+	if err := writer.Write(headers); err != nil {
+		return err
+	}
+
+	for _, site := range sites {
+		for normalizedURL, pageData := range site.Pages {
+			row := append([]string{site.Site}, pageDataRow(normalizedURL, pageData)...)
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// pageDataRow builds the CSV columns for one page, stored under its normalized URL
+func pageDataRow(normalizedURL string, pageData PageData) []string {
+	// Join slices with semicolons as specified
+	outgoingLinks := strings.Join(pageData.OutgoingLinks, ";")
+	imageURLs := strings.Join(pageData.ImageURLs, ";")
+
+	return []string{
+		pageData.URL,
+		pageData.H1,
+		pageData.FirstParagraph,
+		outgoingLinks,
+		imageURLs,
+		pageData.DuplicateOf,
+		normalizedURL,
+		pageData.Canonical,
+	}
+}
+
+// writeOutOfScopeReport exports every out-of-scope URL with the rule that excluded it
+func writeOutOfScopeReport(outOfScope map[string]string, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"url", "rule"}); err != nil {
+		return err
+	}
+
+	// Sort so the report is stable between runs
+	urls := make([]string, 0, len(outOfScope))
+	for rawURL := range outOfScope {
+		urls = append(urls, rawURL)
+	}
+	sort.Strings(urls)
+
+	for _, rawURL := range urls {
+		if err := writer.Write([]string{rawURL, outOfScope[rawURL]}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeTrapsReport exports every URL pattern cut off as a crawler trap
+func writeTrapsReport(trapped map[string]TrapRecord, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"pattern", "reason", "hits"}); err != nil {
+		return err
+	}
+
+	patterns := make([]string, 0, len(trapped))
+	for pattern := range trapped {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		record := trapped[pattern]
+		if err := writer.Write([]string{pattern, record.Reason, strconv.Itoa(record.Hits)}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeStrippedParamsReport exports which tracking and session parameters were removed from which URLs
+func writeStrippedParamsReport(stripped map[string]StrippedURL, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"url", "stripped_url", "stripped_params"}); err != nil {
+		return err
+	}
+
+	rawURLs := make([]string, 0, len(stripped))
+	for rawURL := range stripped {
+		rawURLs = append(rawURLs, rawURL)
+	}
+	sort.Strings(rawURLs)
+
+	for _, rawURL := range rawURLs {
+		record := stripped[rawURL]
+		if err := writer.Write([]string{rawURL, record.URL, strings.Join(record.Params, ";")}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeCanonicalsReport exports each page whose canonical URL names another page
+func writeCanonicalsReport(canonicals map[string]CanonicalLink, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"page_url", "canonical_url", "cross_host", "alias"}); err != nil {
+		return err
+	}
+
+	pageURLs := make([]string, 0, len(canonicals))
+	for pageURL := range canonicals {
+		pageURLs = append(pageURLs, pageURL)
+	}
+	sort.Strings(pageURLs)
+
+	for _, pageURL := range pageURLs {
+		canonical := canonicals[pageURL]
+		row := []string{pageURL, canonical.URL, strconv.FormatBool(canonical.CrossHost), strconv.FormatBool(canonical.Alias)}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeContactsReport exports the email addresses and phone numbers linked from each page
+func writeContactsReport(pages map[string]PageData, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"page_url", "type", "address"}); err != nil {
+		return err
+	}
+
+	rows := [][]string{}
+	for _, pageData := range pages {
+		for _, address := range pageData.EmailLinks {
+			rows = append(rows, []string{pageData.URL, LinkEmail, address})
+		}
+		for _, address := range pageData.PhoneLinks {
+			rows = append(rows, []string{pageData.URL, LinkPhone, address})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		for column := range rows[i] {
+			if rows[i][column] != rows[j][column] {
+				return rows[i][column] < rows[j][column]
+			}
+		}
+		return false
+	})
+
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeUncrawledLinksReport exports the fragment, script, data and other links on each page, with their link type
+func writeUncrawledLinksReport(pages map[string]PageData, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"page_url", "type", "url", "text"}); err != nil {
+		return err
+	}
+
+	// Pages in URL order, links in the order they were found
+	pageURLs := make([]string, 0, len(pages))
+	byURL := make(map[string]PageData, len(pages))
+	for _, pageData := range pages {
+		pageURLs = append(pageURLs, pageData.URL)
+		byURL[pageData.URL] = pageData
+	}
+	sort.Strings(pageURLs)
+
+	for _, pageURL := range pageURLs {
+		for _, link := range byURL[pageURL].UncrawledLinks {
+			if err := writer.Write([]string{pageURL, link.Type, link.URL, link.Text}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeResourcesReport exports every page and static asset each page links to or embeds,
+// with the element and attribute it was found in
+func writeResourcesReport(pages map[string]PageData, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"page_url", "kind", "url", "element", "attribute"}); err != nil {
+		return err
+	}
+
+	// Pages in URL order, resources in the order they were found
+	pageURLs := make([]string, 0, len(pages))
+	byURL := make(map[string]PageData, len(pages))
+	for _, pageData := range pages {
+		pageURLs = append(pageURLs, pageData.URL)
+		byURL[pageData.URL] = pageData
+	}
+	sort.Strings(pageURLs)
+
+	for _, pageURL := range pageURLs {
+		pageData := byURL[pageURL]
+		for _, link := range pageData.Links {
+			if err := writer.Write([]string{pageURL, "document", link.URL, link.Element, link.Attribute}); err != nil {
+				return err
+			}
+		}
+		for _, asset := range pageData.Assets {
+			if err := writer.Write([]string{pageURL, "asset", asset.URL, asset.Element, asset.Attribute}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeChangesReport exports the pages that are new, changed or removed since the previous crawl
+func writeChangesReport(changes map[string]string, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"page_url", "change"}); err != nil {
+		return err
+	}
+
+	normalizedURLs := make([]string, 0, len(changes))
+	for normalizedURL := range changes {
+		normalizedURLs = append(normalizedURLs, normalizedURL)
+	}
+	sort.Strings(normalizedURLs)
+
+	for _, normalizedURL := range normalizedURLs {
+		if err := writer.Write([]string{normalizedURL, changes[normalizedURL]}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeDuplicatesReport exports each cluster of pages serving identical content
+func writeDuplicatesReport(pages map[string]PageData, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"canonical_url", "duplicate_count", "duplicate_urls"}); err != nil {
+		return err
+	}
+
+	for _, cluster := range findDuplicateClusters(pages) {
+		row := []string{
+			cluster.canonicalURL,
+			strconv.Itoa(len(cluster.duplicateURLs)),
+			strings.Join(cluster.duplicateURLs, ";"),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeNearDuplicatesReport exports groups of pages whose content fingerprints are within maxDistance bits
+func writeNearDuplicatesReport(pages map[string]PageData, maxDistance int, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"group_url", "page_url", "hamming_distance", "similarity"}); err != nil {
+		return err
+	}
+
+	for _, group := range findNearDuplicateGroups(pages, maxDistance) {
+		for _, member := range group.members {
+			row := []string{
+				group.representativeURL,
+				member.url,
+				strconv.Itoa(member.distance),
+				strconv.FormatFloat(member.similarity, 'f', 3, 64),
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
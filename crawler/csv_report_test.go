@@ -0,0 +1,568 @@
+package crawler
+
+import (
+	"encoding/csv"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteCSVReport(t *testing.T) {
+	// Create test data
+	testPages := map[string]PageData{
+		"example.com": {
+			URL:            "https://example.com",
+			H1:             "Example Site",
+			FirstParagraph: "This is an example site for testing.",
+			OutgoingLinks:  []string{"https://example.com/about", "https://example.com/contact"},
+			ImageURLs:      []string{"https://example.com/logo.png", "https://example.com/banner.jpg"},
+		},
+		"example.com/about": {
+			URL:            "https://example.com/about",
+			H1:             "About Us",
+			FirstParagraph: "Learn more about our company.",
+			OutgoingLinks:  []string{"https://example.com", "https://example.com/team"},
+			ImageURLs:      []string{"https://example.com/team.jpg"},
+		},
+	}
+
+	// Test filename
+	testFilename := "test_report.csv"
+
+	// Clean up any existing test file
+	defer os.Remove(testFilename)
+
+	// Write CSV report
+	err := writeCSVReport(testPages, testFilename)
+	if err != nil {
+		t.Fatalf("writeCSVReport failed: %v", err)
+	}
+
+	// Verify file exists
+	if _, err := os.Stat(testFilename); os.IsNotExist(err) {
+		t.Fatal("CSV file was not created")
+	}
+
+	// Read and verify CSV content
+	file, err := os.Open(testFilename)
+	if err != nil {
+		t.Fatalf("failed to open CSV file: %v", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV file: %v", err)
+	}
+
+	// Should have header + 2 data rows
+	if len(records) != 3 {
+		t.Errorf("expected 3 rows (1 header + 2 data), got %d", len(records))
+	}
+
+	// Check header
+	expectedHeader := []string{"page_url", "h1", "first_paragraph", "outgoing_link_urls", "image_urls"}
+	if len(records) > 0 {
+		for i, expected := range expectedHeader {
+			if i >= len(records[0]) || records[0][i] != expected {
+				t.Errorf("header column %d: expected '%s', got '%s'", i, expected, records[0][i])
+			}
+		}
+	}
+
+	// Check that data rows contain expected URLs
+	foundURLs := make(map[string]bool)
+	for i := 1; i < len(records); i++ {
+		if len(records[i]) > 0 {
+			foundURLs[records[i][0]] = true
+		}
+	}
+
+	expectedURLs := []string{"https://example.com", "https://example.com/about"}
+	for _, expectedURL := range expectedURLs {
+		if !foundURLs[expectedURL] {
+			t.Errorf("expected to find URL '%s' in CSV data", expectedURL)
+		}
+	}
+
+	// Check that outgoing links are joined with semicolons
+	for i := 1; i < len(records); i++ {
+		if len(records[i]) > 3 && strings.Contains(records[i][3], ";") {
+			// Found semicolon-separated links, which is expected
+			continue
+		} else if len(records[i]) > 3 && records[i][3] == "" {
+			// Empty links column is also acceptable
+			continue
+		}
+		// If we get here, check if it has at least one link without semicolons (single link case)
+		if len(records[i]) > 3 && len(records[i][3]) > 0 && !strings.Contains(records[i][3], ";") {
+			// Single link without semicolon is acceptable
+			continue
+		}
+	}
+}
+
+func TestWriteCSVReportEmpty(t *testing.T) {
+	// Test with empty pages map
+	emptyPages := map[string]PageData{}
+	testFilename := "test_empty_report.csv"
+
+	// Clean up any existing test file
+	defer os.Remove(testFilename)
+
+	// Write CSV report
+	err := writeCSVReport(emptyPages, testFilename)
+	if err != nil {
+		t.Fatalf("writeCSVReport failed with empty data: %v", err)
+	}
+
+	// Verify file exists and has at least header
+	file, err := os.Open(testFilename)
+	if err != nil {
+		t.Fatalf("failed to open CSV file: %v", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV file: %v", err)
+	}
+
+	// Should have only header row
+	if len(records) != 1 {
+		t.Errorf("expected 1 row (header only), got %d", len(records))
+	}
+}
+
+func TestWriteOutOfScopeReport(t *testing.T) {
+	outOfScope := map[string]string{
+		"https://other.com/":        "host not allowed: other.com",
+		"https://example.com/tag/a": "exclude: /tag/",
+	}
+
+	testFilename := "test_out_of_scope.csv"
+	defer os.Remove(testFilename)
+
+	if err := writeOutOfScopeReport(outOfScope, testFilename); err != nil {
+		t.Fatalf("writeOutOfScopeReport failed: %v", err)
+	}
+
+	file, err := os.Open(testFilename)
+	if err != nil {
+		t.Fatalf("failed to open CSV file: %v", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV file: %v", err)
+	}
+
+	// Rows are sorted by URL
+	expected := [][]string{
+		{"url", "rule"},
+		{"https://example.com/tag/a", "exclude: /tag/"},
+		{"https://other.com/", "host not allowed: other.com"},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("expected %v, got %v", expected, records)
+	}
+}
+
+func TestSectionReportFilename(t *testing.T) {
+	actual := sectionReportFilename("report.csv", "out_of_scope")
+	if actual != "report_out_of_scope.csv" {
+		t.Errorf("expected %q, got %q", "report_out_of_scope.csv", actual)
+	}
+}
+
+func TestWriteCombinedCSVReport(t *testing.T) {
+	sites := []SiteResult{
+		{
+			Site:  "example.com",
+			Pages: map[string]PageData{"example.com": {URL: "https://example.com", H1: "Example"}},
+		},
+		{
+			Site:  "other.com",
+			Pages: map[string]PageData{"other.com": {URL: "https://other.com", H1: "Other"}},
+		},
+	}
+
+	testFilename := "test_combined_report.csv"
+	defer os.Remove(testFilename)
+
+	if err := writeCombinedCSVReport(sites, testFilename); err != nil {
+		t.Fatalf("writeCombinedCSVReport failed: %v", err)
+	}
+
+	file, err := os.Open(testFilename)
+	if err != nil {
+		t.Fatalf("failed to open CSV file: %v", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV file: %v", err)
+	}
+
+	expected := [][]string{
+		{"site", "page_url", "h1", "first_paragraph", "outgoing_link_urls", "image_urls", "duplicate_of", "normalized_url", "canonical_url"},
+		{"example.com", "https://example.com", "Example", "", "", "", "", "example.com", ""},
+		{"other.com", "https://other.com", "Other", "", "", "", "", "other.com", ""},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("expected %v, got %v", expected, records)
+	}
+}
+
+func TestWriteTrapsReport(t *testing.T) {
+	trapped := map[string]TrapRecord{
+		"example.com/calendar?*": {Reason: "more than 50 query variants", Hits: 12},
+		"example.com/a/b/a/*":    {Reason: `path segment "a" repeated more than 3 times`, Hits: 1},
+	}
+
+	testFilename := "test_traps.csv"
+	defer os.Remove(testFilename)
+
+	if err := writeTrapsReport(trapped, testFilename); err != nil {
+		t.Fatalf("writeTrapsReport failed: %v", err)
+	}
+
+	file, err := os.Open(testFilename)
+	if err != nil {
+		t.Fatalf("failed to open CSV file: %v", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV file: %v", err)
+	}
+
+	expected := [][]string{
+		{"pattern", "reason", "hits"},
+		{"example.com/a/b/a/*", `path segment "a" repeated more than 3 times`, "1"},
+		{"example.com/calendar?*", "more than 50 query variants", "12"},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("expected %v, got %v", expected, records)
+	}
+}
+
+func TestWriteChangesReport(t *testing.T) {
+	changes := map[string]string{
+		"example.com/new":  ChangeNew,
+		"example.com/gone": ChangeRemoved,
+		"example.com/edit": ChangeChanged,
+	}
+
+	testFilename := "test_changes.csv"
+	defer os.Remove(testFilename)
+
+	if err := writeChangesReport(changes, testFilename); err != nil {
+		t.Fatalf("writeChangesReport failed: %v", err)
+	}
+
+	file, err := os.Open(testFilename)
+	if err != nil {
+		t.Fatalf("failed to open CSV file: %v", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV file: %v", err)
+	}
+
+	expected := [][]string{
+		{"page_url", "change"},
+		{"example.com/edit", "changed"},
+		{"example.com/gone", "removed"},
+		{"example.com/new", "new"},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("expected %v, got %v", expected, records)
+	}
+}
+
+func TestWriteStrippedParamsReport(t *testing.T) {
+	stripped := map[string]StrippedURL{
+		"https://example.com/b?utm_source=x&fbclid=y": {URL: "https://example.com/b", Params: []string{"fbclid", "utm_source"}},
+		"https://example.com/a?id=1&gclid=z":          {URL: "https://example.com/a?id=1", Params: []string{"gclid"}},
+	}
+
+	testFilename := "test_stripped_params.csv"
+	defer os.Remove(testFilename)
+
+	if err := writeStrippedParamsReport(stripped, testFilename); err != nil {
+		t.Fatalf("writeStrippedParamsReport failed: %v", err)
+	}
+
+	file, err := os.Open(testFilename)
+	if err != nil {
+		t.Fatalf("failed to open CSV file: %v", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV file: %v", err)
+	}
+
+	expected := [][]string{
+		{"url", "stripped_url", "stripped_params"},
+		{"https://example.com/a?id=1&gclid=z", "https://example.com/a?id=1", "gclid"},
+		{"https://example.com/b?utm_source=x&fbclid=y", "https://example.com/b", "fbclid;utm_source"},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("expected %v, got %v", expected, records)
+	}
+}
+
+func TestPartialReportFilename(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"report.csv", "report.partial.csv"},
+		{"out/site.csv", "out/site.partial.csv"},
+		{"report", "report.partial"},
+	}
+
+	for _, tc := range tests {
+		actual := PartialReportFilename(tc.input)
+		if actual != tc.expected {
+			t.Errorf("PartialReportFilename(%q): expected %q, got %q", tc.input, tc.expected, actual)
+		}
+	}
+}
+
+func TestCSVPageWriter(t *testing.T) {
+	testFilename := "test_stream_report.csv"
+	defer os.Remove(testFilename)
+
+	w, err := NewCSVPageWriter(testFilename, true)
+	if err != nil {
+		t.Fatalf("NewCSVPageWriter failed: %v", err)
+	}
+	page := PageResult{Site: "example.com", NormalizedURL: "example.com", Page: PageData{URL: "https://example.com", H1: "Example"}}
+	if err := w.Write(page); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// Each row is flushed as soon as it is written
+	file, err := os.Open(testFilename)
+	if err != nil {
+		t.Fatalf("failed to open CSV file: %v", err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV file: %v", err)
+	}
+	if len(records) != 2 || records[1][0] != "example.com" || records[1][1] != "https://example.com" || records[1][7] != "example.com" {
+		t.Errorf("unexpected records before close: %v", records)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+}
+
+func TestWriteCanonicalsReport(t *testing.T) {
+	canonicals := map[string]CanonicalLink{
+		"https://example.com/print": {URL: "https://example.com/article", Alias: true},
+		"https://example.com/ext":   {URL: "https://other.com/x", CrossHost: true},
+	}
+
+	testFilename := "test_canonicals.csv"
+	defer os.Remove(testFilename)
+
+	if err := writeCanonicalsReport(canonicals, testFilename); err != nil {
+		t.Fatalf("writeCanonicalsReport failed: %v", err)
+	}
+
+	file, err := os.Open(testFilename)
+	if err != nil {
+		t.Fatalf("failed to open CSV file: %v", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV file: %v", err)
+	}
+
+	expected := [][]string{
+		{"page_url", "canonical_url", "cross_host", "alias"},
+		{"https://example.com/ext", "https://other.com/x", "true", "false"},
+		{"https://example.com/print", "https://example.com/article", "false", "true"},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("expected %v, got %v", expected, records)
+	}
+}
+
+func TestWriteContactsReport(t *testing.T) {
+	pages := map[string]PageData{
+		"example.com/contact": {URL: "https://example.com/contact", EmailLinks: []string{"sales@example.com"}, PhoneLinks: []string{"+1-555-0100"}},
+		"example.com/about":   {URL: "https://example.com/about", EmailLinks: []string{"team@example.com"}},
+		"example.com":         {URL: "https://example.com"},
+	}
+
+	testFilename := "test_contacts.csv"
+	defer os.Remove(testFilename)
+
+	if err := writeContactsReport(pages, testFilename); err != nil {
+		t.Fatalf("writeContactsReport failed: %v", err)
+	}
+
+	file, err := os.Open(testFilename)
+	if err != nil {
+		t.Fatalf("failed to open CSV file: %v", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV file: %v", err)
+	}
+
+	expected := [][]string{
+		{"page_url", "type", "address"},
+		{"https://example.com/about", "email", "team@example.com"},
+		{"https://example.com/contact", "email", "sales@example.com"},
+		{"https://example.com/contact", "phone", "+1-555-0100"},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("expected %v, got %v", expected, records)
+	}
+}
+
+func TestWriteUncrawledLinksReport(t *testing.T) {
+	pages := map[string]PageData{
+		"example.com/b": {
+			URL:            "https://example.com/b",
+			UncrawledLinks: []Link{{URL: "ftp://files.example.com/a", Text: "FTP", Type: LinkOther}},
+		},
+		"example.com/a": {
+			URL: "https://example.com/a",
+			UncrawledLinks: []Link{
+				{URL: "https://example.com/a#top", Text: "Top", Type: LinkFragment},
+				{URL: "javascript:void(0)", Text: "Menu", Type: LinkScript},
+				{URL: "data:text/plain,hi", Type: LinkData},
+			},
+		},
+		"example.com": {URL: "https://example.com"},
+	}
+
+	testFilename := "test_uncrawled_links.csv"
+	defer os.Remove(testFilename)
+
+	if err := writeUncrawledLinksReport(pages, testFilename); err != nil {
+		t.Fatalf("writeUncrawledLinksReport failed: %v", err)
+	}
+
+	file, err := os.Open(testFilename)
+	if err != nil {
+		t.Fatalf("failed to open CSV file: %v", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV file: %v", err)
+	}
+
+	expected := [][]string{
+		{"page_url", "type", "url", "text"},
+		{"https://example.com/a", "fragment", "https://example.com/a#top", "Top"},
+		{"https://example.com/a", "script", "javascript:void(0)", "Menu"},
+		{"https://example.com/a", "data", "data:text/plain,hi", ""},
+		{"https://example.com/b", "other", "ftp://files.example.com/a", "FTP"},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("expected %v, got %v", expected, records)
+	}
+}
+
+func TestWriteResourcesReport(t *testing.T) {
+	pages := map[string]PageData{
+		"example.com/b": {
+			URL:    "https://example.com/b",
+			Assets: []DiscoveredURL{{URL: "https://example.com/bg.png", Element: "style"}},
+		},
+		"example.com/a": {
+			URL:    "https://example.com/a",
+			Links:  []Link{{URL: "https://example.com/embed", Element: "iframe", Attribute: "src"}},
+			Assets: []DiscoveredURL{{URL: "https://example.com/app.js", Element: "script", Attribute: "src"}},
+		},
+	}
+
+	testFilename := "test_resources.csv"
+	defer os.Remove(testFilename)
+
+	if err := writeResourcesReport(pages, testFilename); err != nil {
+		t.Fatalf("writeResourcesReport failed: %v", err)
+	}
+
+	file, err := os.Open(testFilename)
+	if err != nil {
+		t.Fatalf("failed to open CSV file: %v", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV file: %v", err)
+	}
+
+	expected := [][]string{
+		{"page_url", "kind", "url", "element", "attribute"},
+		{"https://example.com/a", "document", "https://example.com/embed", "iframe", "src"},
+		{"https://example.com/a", "asset", "https://example.com/app.js", "script", "src"},
+		{"https://example.com/b", "asset", "https://example.com/bg.png", "style", ""},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("expected %v, got %v", expected, records)
+	}
+}
+
+func TestWriteJSONLinesReport(t *testing.T) {
+	link := Link{URL: "https://example.com/a", Text: "A", Rel: []string{"nofollow"}, Element: "a", Attribute: "href"}
+	sites := []SiteResult{{
+		Site:  "example.com",
+		Pages: map[string]PageData{"example.com": {URL: "https://example.com", OutgoingLinks: []string{link.URL}, Links: []Link{link}}},
+	}}
+
+	testFilename := "test_report.jsonl"
+	defer os.Remove(testFilename)
+
+	if err := writeJSONLinesReport(sites, testFilename); err != nil {
+		t.Fatalf("writeJSONLinesReport failed: %v", err)
+	}
+
+	file, err := os.Open(testFilename)
+	if err != nil {
+		t.Fatalf("failed to open JSON Lines file: %v", err)
+	}
+	defer file.Close()
+
+	pages, err := ReadState(file)
+	if err != nil {
+		t.Fatalf("failed to read JSON Lines file: %v", err)
+	}
+	if len(pages) != 1 || !reflect.DeepEqual(pages[0].Page.Links, []Link{link}) {
+		t.Errorf("expected the full link records, got %+v", pages)
+	}
+}
+
+func TestJSONLinesReportFilename(t *testing.T) {
+	if got := jsonLinesReportFilename("report.csv"); got != "report.jsonl" {
+		t.Errorf("expected report.jsonl, got %s", got)
+	}
+}
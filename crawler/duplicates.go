@@ -0,0 +1,66 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+)
+
+// contentHash fingerprints a page's main content, or returns "" when there is none
+func contentHash(content string) string {
+	if content == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// markDuplicate records a page's content hash, pointing DuplicateOf at the first page with the same content
+func (cfg *config) markDuplicate(pageData *PageData) (isDuplicate bool) {
+	if pageData.ContentHash == "" {
+		return false
+	}
+
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+
+	if cfg.contentHashes == nil {
+		cfg.contentHashes = make(map[string]string)
+	}
+	if canonicalURL, exists := cfg.contentHashes[pageData.ContentHash]; exists {
+		pageData.DuplicateOf = canonicalURL
+		return true
+	}
+
+	if cfg.roomForRecord(len(cfg.contentHashes)) {
+		cfg.contentHashes[pageData.ContentHash] = pageData.URL
+	}
+	return false
+}
+
+// duplicateCluster is a canonical page and the pages serving identical content
+type duplicateCluster struct {
+	canonicalURL  string
+	duplicateURLs []string
+}
+
+// findDuplicateClusters groups crawled pages by the page they duplicate
+func findDuplicateClusters(pages map[string]PageData) []duplicateCluster {
+	duplicates := make(map[string][]string)
+	for _, pageData := range pages {
+		if pageData.DuplicateOf != "" {
+			duplicates[pageData.DuplicateOf] = append(duplicates[pageData.DuplicateOf], pageData.URL)
+		}
+	}
+
+	clusters := make([]duplicateCluster, 0, len(duplicates))
+	for canonicalURL, duplicateURLs := range duplicates {
+		sort.Strings(duplicateURLs)
+		clusters = append(clusters, duplicateCluster{canonicalURL: canonicalURL, duplicateURLs: duplicateURLs})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].canonicalURL < clusters[j].canonicalURL
+	})
+
+	return clusters
+}
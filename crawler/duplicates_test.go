@@ -0,0 +1,54 @@
+package crawler
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestMarkDuplicate(t *testing.T) {
+	cfg := &config{
+		pages: make(map[string]PageData),
+		mu:    &sync.Mutex{},
+	}
+
+	first := PageData{URL: "https://example.com/post", ContentHash: contentHash("same content")}
+	if cfg.markDuplicate(&first) {
+		t.Error("expected first page not to be a duplicate")
+	}
+
+	printView := PageData{URL: "https://example.com/post/print", ContentHash: contentHash("same content")}
+	if !cfg.markDuplicate(&printView) {
+		t.Error("expected page with identical content to be a duplicate")
+	}
+	if printView.DuplicateOf != first.URL {
+		t.Errorf("expected DuplicateOf %q, got %q", first.URL, printView.DuplicateOf)
+	}
+
+	// Pages without content never collide with each other
+	empty1 := PageData{URL: "https://example.com/empty1"}
+	empty2 := PageData{URL: "https://example.com/empty2"}
+	if cfg.markDuplicate(&empty1) || cfg.markDuplicate(&empty2) {
+		t.Error("expected pages without content not to be duplicates")
+	}
+}
+
+func TestFindDuplicateClusters(t *testing.T) {
+	pages := map[string]PageData{
+		"example.com/a":       {URL: "https://example.com/a"},
+		"example.com/a/print": {URL: "https://example.com/a/print", DuplicateOf: "https://example.com/a"},
+		"example.com/a/amp":   {URL: "https://example.com/a/amp", DuplicateOf: "https://example.com/a"},
+		"example.com/b":       {URL: "https://example.com/b"},
+	}
+
+	actual := findDuplicateClusters(pages)
+	expected := []duplicateCluster{
+		{
+			canonicalURL:  "https://example.com/a",
+			duplicateURLs: []string{"https://example.com/a/amp", "https://example.com/a/print"},
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+}
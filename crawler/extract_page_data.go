@@ -0,0 +1,52 @@
+package crawler
+
+import (
+	"net/url"
+	"time"
+)
+
+type PageData struct {
+	URL            string          `json:"url"`
+	H1             string          `json:"h1"`
+	FirstParagraph string          `json:"first_paragraph"`
+	OutgoingLinks  []string        `json:"outgoing_links"`
+	ImageURLs      []string        `json:"image_urls"`
+	EmailLinks     []string        `json:"email_links,omitempty"`     // Addresses of mailto: links
+	PhoneLinks     []string        `json:"phone_links,omitempty"`     // Numbers of tel: links
+	Links          []Link          `json:"links,omitempty"`           // Pages linked or embedded, with their anchor attributes
+	UncrawledLinks []Link          `json:"uncrawled_links,omitempty"` // Fragment, script, data and other links, which are never crawled
+	Assets         []DiscoveredURL `json:"assets,omitempty"`          // Stylesheets, scripts, images, media and other static assets
+	ContentHash    string          `json:"content_hash,omitempty"`    // SHA-256 of the page's main content text
+	SimHash        uint64          `json:"simhash,omitempty"`         // SimHash fingerprint of the main content text, for near-duplicates
+	DuplicateOf    string          `json:"duplicate_of,omitempty"`    // URL of the first page with identical content, if any
+	Canonical      string          `json:"canonical,omitempty"`       // Absolute rel=canonical URL, if the page declares one
+
+	// Validators for conditional recrawls
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"` // Last-Modified header as sent by the server
+	CrawledAt    time.Time `json:"crawled_at,omitzero"`     // When the content was fetched
+}
+
+// extractPageData parses a page once and runs every extractor over it
+func extractPageData(html, pageURL string) PageData {
+	pageData := PageData{
+		URL:           pageURL,
+		OutgoingLinks: []string{},
+		ImageURLs:     []string{},
+	}
+
+	// If either the URL or the HTML can't be parsed, return empty PageData with the original URL
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil {
+		return pageData
+	}
+	doc, err := parseDocument(html, parsedURL)
+	if err != nil {
+		return pageData
+	}
+
+	for _, e := range pageExtractors {
+		e.extract(doc, &pageData)
+	}
+	return pageData
+}
@@ -0,0 +1,166 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractPageData(t *testing.T) {
+	inputURL := "https://blog.boot.dev"
+	inputBody := `<html><body>
+        <h1>Test Title</h1>
+        <p>This is the first paragraph.</p>
+        <a href="/link1">Link 1</a>
+        <img src="/image1.jpg" alt="Image 1">
+    </body></html>`
+
+	actual := extractPageData(inputBody, inputURL)
+
+	expected := PageData{
+		URL:            "https://blog.boot.dev",
+		H1:             "Test Title",
+		FirstParagraph: "This is the first paragraph.",
+		OutgoingLinks:  []string{"https://blog.boot.dev/link1"},
+		ImageURLs:      []string{"https://blog.boot.dev/image1.jpg"},
+		Links:          []Link{{URL: "https://blog.boot.dev/link1", Text: "Link 1", Element: "a", Attribute: "href", Type: LinkNavigational}},
+		Assets:         []DiscoveredURL{{URL: "https://blog.boot.dev/image1.jpg", Element: "img", Attribute: "src"}},
+		ContentHash:    contentHash("Test Title This is the first paragraph. Link 1"),
+		SimHash:        simHash("Test Title This is the first paragraph. Link 1"),
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestExtractPageDataWithMain(t *testing.T) {
+	inputURL := "https://example.com"
+	inputBody := `<html><body>
+		<h1>Page Title</h1>
+		<p>Outside paragraph.</p>
+		<main>
+			<p>Main paragraph.</p>
+		</main>
+		<a href="https://other.com">External</a>
+		<a href="/internal">Internal</a>
+		<img src="/logo.png" alt="Logo">
+	</body></html>`
+
+	actual := extractPageData(inputBody, inputURL)
+
+	expected := PageData{
+		URL:            "https://example.com",
+		H1:             "Page Title",
+		FirstParagraph: "Main paragraph.",
+		OutgoingLinks:  []string{"https://other.com", "https://example.com/internal"},
+		ImageURLs:      []string{"https://example.com/logo.png"},
+		Links: []Link{
+			{URL: "https://other.com", Text: "External", Element: "a", Attribute: "href", Type: LinkNavigational},
+			{URL: "https://example.com/internal", Text: "Internal", Element: "a", Attribute: "href", Type: LinkNavigational},
+		},
+		Assets:      []DiscoveredURL{{URL: "https://example.com/logo.png", Element: "img", Attribute: "src"}},
+		ContentHash: contentHash("Main paragraph."),
+		SimHash:     simHash("Main paragraph."),
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestExtractPageDataEmpty(t *testing.T) {
+	inputURL := "https://empty.com"
+	inputBody := `<html><body></body></html>`
+
+	actual := extractPageData(inputBody, inputURL)
+
+	expected := PageData{
+		URL:            "https://empty.com",
+		H1:             "",
+		FirstParagraph: "",
+		OutgoingLinks:  []string{},
+		ImageURLs:      []string{},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestExtractPageDataContactLinks(t *testing.T) {
+	inputURL := "https://example.com"
+	inputBody := `<html><body>
+		<a href="/contact">Contact</a>
+		<a href="mailto:sales@example.com">Sales</a>
+		<a href="tel:+1-555-0100">Call</a>
+		<a href="#top">Top</a>
+		<a href="javascript:void(0)">Menu</a>
+		<a href="data:text/plain,hi">Data</a>
+		<a href="ftp://files.example.com/a">FTP</a>
+	</body></html>`
+
+	actual := extractPageData(inputBody, inputURL)
+
+	if expected := []string{"https://example.com/contact"}; !reflect.DeepEqual(actual.OutgoingLinks, expected) {
+		t.Errorf("expected outgoing links %v, got %v", expected, actual.OutgoingLinks)
+	}
+	if expected := []string{"sales@example.com"}; !reflect.DeepEqual(actual.EmailLinks, expected) {
+		t.Errorf("expected email links %v, got %v", expected, actual.EmailLinks)
+	}
+	if expected := []string{"+1-555-0100"}; !reflect.DeepEqual(actual.PhoneLinks, expected) {
+		t.Errorf("expected phone links %v, got %v", expected, actual.PhoneLinks)
+	}
+
+	// Links that are never crawled are still recorded with their type
+	expectedUncrawled := []Link{
+		{URL: "https://example.com#top", Text: "Top", Element: "a", Attribute: "href", Type: LinkFragment},
+		{URL: "javascript:void(0)", Text: "Menu", Element: "a", Attribute: "href", Type: LinkScript},
+		{URL: "data:text/plain,hi", Text: "Data", Element: "a", Attribute: "href", Type: LinkData},
+		{URL: "ftp://files.example.com/a", Text: "FTP", Element: "a", Attribute: "href", Type: LinkOther},
+	}
+	if !reflect.DeepEqual(actual.UncrawledLinks, expectedUncrawled) {
+		t.Errorf("expected uncrawled links %v, got %v", expectedUncrawled, actual.UncrawledLinks)
+	}
+}
+
+// largePage builds a page with many paragraphs, links and images
+func largePage() string {
+	var b strings.Builder
+	b.WriteString(`<html><head><link rel="canonical" href="/large"><script>var x = 1;</script></head><body><h1>Large Page</h1><main>`)
+	for i := range 2000 {
+		fmt.Fprintf(&b, `<p>Paragraph %d with <a href="/page/%d">a link</a> and <a href="mailto:user%d@example.com">mail</a>.</p>`, i, i, i)
+		if i%4 == 0 {
+			fmt.Fprintf(&b, `<img src="/images/%d.png" alt="Image %d">`, i, i)
+		}
+	}
+	b.WriteString(`</main></body></html>`)
+	return b.String()
+}
+
+func BenchmarkExtractPageData(b *testing.B) {
+	page := largePage()
+	b.SetBytes(int64(len(page)))
+	for b.Loop() {
+		extractPageData(page, "https://example.com/large")
+	}
+}
+
+// BenchmarkExtractPageDataParsePerField parses the page once per field, plus once more for the
+// links to crawl, as extraction did before the parsed document was shared
+func BenchmarkExtractPageDataParsePerField(b *testing.B) {
+	page := largePage()
+	pageURL, _ := url.Parse("https://example.com/large")
+	b.SetBytes(int64(len(page)))
+	for b.Loop() {
+		getH1FromHTML(page)
+		getFirstParagraphFromHTML(page)
+		getLinksFromHTML(page, pageURL)
+		getImagesFromHTML(page, pageURL)
+		getCanonicalFromHTML(page, pageURL)
+		getMainContentFromHTML(page)
+		getURLsFromHTML(page, pageURL)
+	}
+}
@@ -0,0 +1,91 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// document is a page parsed once and shared by every extractor
+type document struct {
+	*goquery.Document
+	pageURL *url.URL // URL the page was fetched from
+	baseURL *url.URL // URL relative URLs resolve against, from <base href> or the page URL
+}
+
+// parseDocument parses a page's HTML, resolving its base URL against pageURL if there is one
+func parseDocument(htmlBody string, pageURL *url.URL) (*document, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlBody))
+	if err != nil {
+		return nil, err
+	}
+
+	// Pages parsed for their text alone have no URL to resolve against
+	parsed := &document{Document: doc, pageURL: pageURL}
+	if pageURL != nil {
+		parsed.baseURL = documentBaseURL(doc, pageURL)
+	}
+	return parsed, nil
+}
+
+// extractor fills in part of a page's data from its parsed document. Extractors only read the
+// document, so any number of them can share one parse.
+type extractor interface {
+	extract(doc *document, pageData *PageData)
+}
+
+// extractorFunc adapts a plain function to the extractor interface
+type extractorFunc func(doc *document, pageData *PageData)
+
+func (f extractorFunc) extract(doc *document, pageData *PageData) {
+	f(doc, pageData)
+}
+
+// pageExtractors fill in every extracted PageData field, in order
+var pageExtractors = []extractor{
+	extractorFunc(func(doc *document, pageData *PageData) {
+		pageData.H1 = h1FromDocument(doc)
+	}),
+	extractorFunc(func(doc *document, pageData *PageData) {
+		pageData.FirstParagraph = firstParagraphFromDocument(doc)
+	}),
+	extractorFunc(extractLinks),
+	extractorFunc(func(doc *document, pageData *PageData) {
+		pageData.ImageURLs = imagesFromDocument(doc)
+	}),
+	extractorFunc(func(doc *document, pageData *PageData) {
+		pageData.Canonical = canonicalFromDocument(doc)
+	}),
+	extractorFunc(func(doc *document, pageData *PageData) {
+		// Fingerprint the main content for exact and near-duplicate detection
+		mainContent := mainContentFromDocument(doc)
+		pageData.ContentHash = contentHash(mainContent)
+		pageData.SimHash = simHash(mainContent)
+	}),
+}
+
+// extractLinks fills in the pages to crawl and the static assets, keeping contact and uncrawled links apart
+func extractLinks(doc *document, pageData *PageData) {
+	for _, link := range linksFromDocument(doc) {
+		switch link.Type {
+		case LinkNavigational:
+			pageData.Links = append(pageData.Links, link)
+		case LinkEmail:
+			pageData.EmailLinks = append(pageData.EmailLinks, contactAddress(link.URL))
+		case LinkPhone:
+			pageData.PhoneLinks = append(pageData.PhoneLinks, contactAddress(link.URL))
+		default:
+			pageData.UncrawledLinks = append(pageData.UncrawledLinks, link)
+		}
+	}
+
+	documents, assets := resourcesFromDocument(doc)
+	pageData.Links = append(pageData.Links, documents...)
+	pageData.Assets = assets
+
+	pageData.OutgoingLinks = []string{}
+	for _, link := range pageData.Links {
+		pageData.OutgoingLinks = append(pageData.OutgoingLinks, link.URL)
+	}
+}
@@ -0,0 +1,60 @@
+package crawler
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// h1FromDocument returns the text of the first <h1>
+func h1FromDocument(doc *document) string {
+	h1 := doc.Find("h1").First()
+	return strings.TrimSpace(h1.Text())
+}
+
+// firstParagraphFromDocument returns the text of the first <p> in <main>, or in the whole page
+func firstParagraphFromDocument(doc *document) string {
+	// First, try to find a <p> tag within <main>
+	mainSection := doc.Find("main")
+	if mainSection.Length() > 0 {
+		paragraph := mainSection.Find("p").First()
+		if paragraph.Length() > 0 {
+			return strings.TrimSpace(paragraph.Text())
+		}
+	}
+
+	// Fallback: find first <p> tag in the entire document
+	paragraph := doc.Find("p").First()
+	return strings.TrimSpace(paragraph.Text())
+}
+
+// mainContentFromDocument returns the visible text of <main>, or of <body> when there is no <main>
+func mainContentFromDocument(doc *document) string {
+	content := doc.Find("main").First()
+	if content.Length() == 0 {
+		content = doc.Find("body")
+	}
+
+	// Join text nodes with spaces so adjacent elements don't run together,
+	// and collapse whitespace so formatting changes don't affect the result.
+	// Scripts and styles aren't content, and are skipped rather than removed
+	// so the shared document stays intact for other extractors.
+	words := []string{}
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style" || n.Data == "noscript") {
+			return
+		}
+		if n.Type == html.TextNode {
+			words = append(words, strings.Fields(n.Data)...)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+	}
+	for _, node := range content.Nodes {
+		collect(node)
+	}
+
+	return strings.Join(words, " ")
+}
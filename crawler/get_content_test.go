@@ -1,4 +1,4 @@
-package main
+package crawler
 
 import "testing"
 
@@ -90,3 +90,58 @@ func TestGetFirstParagraphFromHTMLEmpty(t *testing.T) {
 		t.Errorf("expected %q, got %q", expected, actual)
 	}
 }
+
+func TestGetMainContentFromHTML(t *testing.T) {
+	inputBody := `<html><body>
+		<nav>Menu</nav>
+		<main>
+			<h1>Title</h1>
+			<script>var tracking = 1;</script>
+			<p>Some
+			   content.</p>
+		</main>
+	</body></html>`
+	actual := getMainContentFromHTML(inputBody)
+	expected := "Title Some content."
+
+	if actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestGetMainContentFromHTMLNoMain(t *testing.T) {
+	inputBody := "<html><body><h1>Title</h1><p>Body text.</p></body></html>"
+	actual := getMainContentFromHTML(inputBody)
+	expected := "Title Body text."
+
+	if actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+// getH1FromHTML parses a page and returns the text of the first <h1>
+func getH1FromHTML(html string) string {
+	doc, err := parseDocument(html, nil)
+	if err != nil {
+		return ""
+	}
+	return h1FromDocument(doc)
+}
+
+// getFirstParagraphFromHTML parses a page and returns its first paragraph
+func getFirstParagraphFromHTML(html string) string {
+	doc, err := parseDocument(html, nil)
+	if err != nil {
+		return ""
+	}
+	return firstParagraphFromDocument(doc)
+}
+
+// getMainContentFromHTML returns the visible text of <main>, or of <body> when there is no <main>
+func getMainContentFromHTML(rawHTML string) string {
+	doc, err := parseDocument(rawHTML, nil)
+	if err != nil {
+		return ""
+	}
+	return mainContentFromDocument(doc)
+}
@@ -0,0 +1,124 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// requestTimeout bounds each fetch so in-flight pages can't stall a shutdown
+const requestTimeout = 30 * time.Second
+
+// fetchResult is a fetched page and the validators needed to fetch it conditionally next time
+type fetchResult struct {
+	html          string
+	statusCode    int    // 0 if no response arrived
+	bytes         int64  // Size of the body read
+	contentType   string // Media type of the response, without parameters
+	errorCategory string // Why the fetch failed, for statistics
+	notModified   bool   // The server answered a conditional request with 304
+	etag          string
+	lastModified  string
+}
+
+// fetchPage fetches a page, conditionally on the validators of a previous crawl when given one
+func fetchPage(rawURL string, h *hooks, previous *PageData) (fetchResult, error) {
+	// Create a new HTTP client
+	client := &http.Client{Timeout: requestTimeout}
+
+	// Create a new request
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return fetchResult{errorCategory: "invalid_request"}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set User-Agent header
+	req.Header.Set("User-Agent", "BootCrawler/1.0")
+
+	// Ask the server to skip the body if the page hasn't changed since the previous crawl
+	if previous != nil {
+		if previous.ETag != "" {
+			req.Header.Set("If-None-Match", previous.ETag)
+		}
+		if previous.LastModified != "" {
+			req.Header.Set("If-Modified-Since", previous.LastModified)
+		}
+	}
+
+	// Let hooks modify the request, e.g. to sign it
+	if err := h.request(req); err != nil {
+		return fetchResult{errorCategory: "hook"}, fmt.Errorf("request hook: %w", err)
+	}
+
+	// Make the request
+	resp, err := client.Do(req)
+	if err != nil {
+		return fetchResult{errorCategory: networkErrorCategory(err)}, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	result := fetchResult{
+		statusCode:   resp.StatusCode,
+		contentType:  mediaType(contentType),
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}
+
+	// Let hooks inspect the response before we decide what to do with it
+	if err := h.response(resp); err != nil {
+		result.errorCategory = "hook"
+		return result, fmt.Errorf("response hook: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotModified && previous != nil {
+		result.notModified = true
+		return result, nil
+	}
+
+	// Check for HTTP error status codes (400+)
+	if resp.StatusCode >= 400 {
+		result.errorCategory = fmt.Sprintf("http_%dxx", resp.StatusCode/100)
+		return result, fmt.Errorf("HTTP error: status code %d", resp.StatusCode)
+	}
+
+	// Check content-type header
+	if !strings.Contains(contentType, "text/html") {
+		result.errorCategory = "content_type"
+		return result, fmt.Errorf("invalid content type: %s (expected text/html)", contentType)
+	}
+
+	// Read the response body
+	htmlBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		result.errorCategory = networkErrorCategory(err)
+		return result, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	result.html = string(htmlBytes)
+	result.bytes = int64(len(htmlBytes))
+	return result, nil
+}
+
+// networkErrorCategory classifies a failed request for statistics
+func networkErrorCategory(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &dnsErr):
+		return "dns"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	default:
+		return "connection"
+	}
+}
+
+// mediaType strips parameters such as charset from a Content-Type header
+func mediaType(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
@@ -0,0 +1,104 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// linksFromDocument resolves every a[href] and area[href] in a parsed page and classifies it by link type
+func linksFromDocument(doc *document) []Link {
+	links := []Link{}
+	doc.Find("a[href], area[href]").Each(func(_ int, s *goquery.Selection) {
+		href, exists := s.Attr("href")
+		if !exists {
+			return
+		}
+
+		// Parse the href to handle relative URLs
+		parsedURL, err := url.Parse(strings.TrimSpace(href))
+		if err != nil {
+			return
+		}
+
+		// Resolve relative URLs against the base URL
+		absoluteURL := doc.baseURL.ResolveReference(parsedURL)
+		link := newLink(s, absoluteURL.String(), "href")
+		link.Type = classifyLink(href, absoluteURL, doc.pageURL)
+		links = append(links, link)
+	})
+
+	return links
+}
+
+// imagesFromDocument resolves every img[src] in a parsed page
+func imagesFromDocument(doc *document) []string {
+	images := []string{}
+	doc.Find("img[src]").Each(func(_ int, s *goquery.Selection) {
+		src, exists := s.Attr("src")
+		if !exists || src == "" {
+			return
+		}
+
+		// Parse the src to handle relative URLs
+		parsedURL, err := url.Parse(src)
+		if err != nil {
+			return
+		}
+
+		// Resolve relative URLs against the base URL
+		absoluteURL := doc.baseURL.ResolveReference(parsedURL)
+		images = append(images, absoluteURL.String())
+	})
+
+	return images
+}
+
+// canonicalFromDocument returns the absolute URL of the first <link rel="canonical"> in a parsed page
+func canonicalFromDocument(doc *document) string {
+	canonical := ""
+	doc.Find("link[rel][href]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		// rel holds a space-separated list of link types
+		rel, _ := s.Attr("rel")
+		isCanonical := false
+		for _, linkType := range strings.Fields(rel) {
+			if strings.EqualFold(linkType, "canonical") {
+				isCanonical = true
+			}
+		}
+		if !isCanonical {
+			return true
+		}
+
+		href, _ := s.Attr("href")
+		parsedURL, err := url.Parse(strings.TrimSpace(href))
+		if err != nil || strings.TrimSpace(href) == "" {
+			return true
+		}
+		canonical = doc.baseURL.ResolveReference(parsedURL).String()
+		return false
+	})
+
+	return canonical
+}
+
+// documentBaseURL returns the URL that relative URLs on a page resolve against: the first <base href>,
+// itself resolved against the page URL, or the page URL when there is none
+func documentBaseURL(doc *goquery.Document, pageURL *url.URL) *url.URL {
+	href, exists := doc.Find("base[href]").First().Attr("href")
+	if !exists {
+		return pageURL
+	}
+	parsedURL, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return pageURL
+	}
+
+	// Browsers ignore data: and javascript: base URLs
+	baseURL := pageURL.ResolveReference(parsedURL)
+	if scheme := strings.ToLower(baseURL.Scheme); scheme == "data" || scheme == "javascript" {
+		return pageURL
+	}
+	return baseURL
+}
@@ -0,0 +1,299 @@
+package crawler
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestGetURLsFromHTMLAbsolute(t *testing.T) {
+	inputURL := "https://blog.boot.dev"
+	inputBody := `<html><body><a href="https://blog.boot.dev"><span>Boot.dev</span></a></body></html>`
+
+	baseURL, err := url.Parse(inputURL)
+	if err != nil {
+		t.Errorf("couldn't parse input URL: %v", err)
+		return
+	}
+
+	actual, err := getURLsFromHTML(inputBody, baseURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"https://blog.boot.dev"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestGetURLsFromHTMLRelative(t *testing.T) {
+	inputURL := "https://blog.boot.dev"
+	inputBody := `<html><body><a href="/path/to/page"><span>Page</span></a></body></html>`
+
+	baseURL, err := url.Parse(inputURL)
+	if err != nil {
+		t.Errorf("couldn't parse input URL: %v", err)
+		return
+	}
+
+	actual, err := getURLsFromHTML(inputBody, baseURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"https://blog.boot.dev/path/to/page"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestGetURLsFromHTMLMultiple(t *testing.T) {
+	inputURL := "https://blog.boot.dev"
+	inputBody := `<html><body>
+		<a href="/path1">Link 1</a>
+		<a href="https://other.com">Link 2</a>
+		<a href="/path2">Link 3</a>
+	</body></html>`
+
+	baseURL, err := url.Parse(inputURL)
+	if err != nil {
+		t.Errorf("couldn't parse input URL: %v", err)
+		return
+	}
+
+	actual, err := getURLsFromHTML(inputBody, baseURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"https://blog.boot.dev/path1",
+		"https://other.com",
+		"https://blog.boot.dev/path2",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestGetImagesFromHTMLRelative(t *testing.T) {
+	inputURL := "https://blog.boot.dev"
+	inputBody := `<html><body><img src="/logo.png" alt="Logo"></body></html>`
+
+	baseURL, err := url.Parse(inputURL)
+	if err != nil {
+		t.Errorf("couldn't parse input URL: %v", err)
+		return
+	}
+
+	actual, err := getImagesFromHTML(inputBody, baseURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"https://blog.boot.dev/logo.png"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestGetImagesFromHTMLAbsolute(t *testing.T) {
+	inputURL := "https://blog.boot.dev"
+	inputBody := `<html><body><img src="https://example.com/image.jpg" alt="Image"></body></html>`
+
+	baseURL, err := url.Parse(inputURL)
+	if err != nil {
+		t.Errorf("couldn't parse input URL: %v", err)
+		return
+	}
+
+	actual, err := getImagesFromHTML(inputBody, baseURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"https://example.com/image.jpg"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestGetImagesFromHTMLMultiple(t *testing.T) {
+	inputURL := "https://blog.boot.dev"
+	inputBody := `<html><body>
+		<img src="/img1.png" alt="Image 1">
+		<img src="https://cdn.com/img2.jpg" alt="Image 2">
+		<img src="/img3.gif">
+	</body></html>`
+
+	baseURL, err := url.Parse(inputURL)
+	if err != nil {
+		t.Errorf("couldn't parse input URL: %v", err)
+		return
+	}
+
+	actual, err := getImagesFromHTML(inputBody, baseURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"https://blog.boot.dev/img1.png",
+		"https://cdn.com/img2.jpg",
+		"https://blog.boot.dev/img3.gif",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestGetCanonicalFromHTML(t *testing.T) {
+	tests := []struct {
+		name      string
+		inputBody string
+		expected  string
+	}{
+		{
+			name:      "relative canonical",
+			inputBody: `<html><head><link rel="canonical" href="/article"></head></html>`,
+			expected:  "https://blog.boot.dev/article",
+		},
+		{
+			name:      "rel with several link types",
+			inputBody: `<html><head><link rel="alternate CANONICAL" href="https://other.com/x"></head></html>`,
+			expected:  "https://other.com/x",
+		},
+		{
+			name:      "first canonical wins",
+			inputBody: `<html><head><link rel="stylesheet" href="/s.css"><link rel="canonical" href="/a"><link rel="canonical" href="/b"></head></html>`,
+			expected:  "https://blog.boot.dev/a",
+		},
+		{
+			name:      "no canonical",
+			inputBody: `<html><head><link rel="canonical" href=""></head></html>`,
+			expected:  "",
+		},
+	}
+
+	baseURL, err := url.Parse("https://blog.boot.dev/post")
+	if err != nil {
+		t.Fatalf("couldn't parse input URL: %v", err)
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := getCanonicalFromHTML(tc.inputBody, baseURL); actual != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestBaseElementResolution(t *testing.T) {
+	tests := []struct {
+		name      string
+		baseHref  string
+		links     []string
+		images    []string
+		canonical string
+	}{
+		{
+			name:      "no base element",
+			links:     []string{"https://blog.boot.dev/docs/intro", "https://blog.boot.dev/root"},
+			images:    []string{"https://blog.boot.dev/docs/logo.png"},
+			canonical: "https://blog.boot.dev/docs/page",
+		},
+		{
+			name:      "absolute base",
+			baseHref:  `<base href="https://cdn.boot.dev/v2/">`,
+			links:     []string{"https://cdn.boot.dev/v2/intro", "https://cdn.boot.dev/root", "https://cdn.boot.dev/v2/#top"},
+			images:    []string{"https://cdn.boot.dev/v2/logo.png"},
+			canonical: "https://cdn.boot.dev/v2/page",
+		},
+		{
+			name:      "relative base",
+			baseHref:  `<base href="../static/">`,
+			links:     []string{"https://blog.boot.dev/static/intro", "https://blog.boot.dev/root", "https://blog.boot.dev/static/#top"},
+			images:    []string{"https://blog.boot.dev/static/logo.png"},
+			canonical: "https://blog.boot.dev/static/page",
+		},
+		{
+			name:      "javascript base is ignored",
+			baseHref:  `<base href="javascript:void(0)">`,
+			links:     []string{"https://blog.boot.dev/docs/intro", "https://blog.boot.dev/root"},
+			images:    []string{"https://blog.boot.dev/docs/logo.png"},
+			canonical: "https://blog.boot.dev/docs/page",
+		},
+	}
+
+	pageURL, err := url.Parse("https://blog.boot.dev/docs/page")
+	if err != nil {
+		t.Fatalf("couldn't parse input URL: %v", err)
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			inputBody := `<html><head>` + tc.baseHref + `<link rel="canonical" href="page"></head><body>
+				<a href="intro">Intro</a><a href="/root">Root</a><a href="#top">Top</a><img src="logo.png">
+			</body></html>`
+
+			links, err := getURLsFromHTML(inputBody, pageURL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(links, tc.links) {
+				t.Errorf("expected links %v, got %v", tc.links, links)
+			}
+
+			images, err := getImagesFromHTML(inputBody, pageURL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(images, tc.images) {
+				t.Errorf("expected images %v, got %v", tc.images, images)
+			}
+
+			if canonical := getCanonicalFromHTML(inputBody, pageURL); canonical != tc.canonical {
+				t.Errorf("expected canonical %v, got %v", tc.canonical, canonical)
+			}
+		})
+	}
+}
+
+// getURLsFromHTML returns the crawlable HTTP(S) pages a page links to or embeds
+func getURLsFromHTML(htmlBody string, baseURL *url.URL) ([]string, error) {
+	doc, err := parseDocument(htmlBody, baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var pageData PageData
+	extractLinks(doc, &pageData)
+	return pageData.OutgoingLinks, nil
+}
+
+// getLinksFromHTML resolves every a[href] and area[href] on a page and classifies it by link type
+func getLinksFromHTML(htmlBody string, pageURL *url.URL) ([]Link, error) {
+	doc, err := parseDocument(htmlBody, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	return linksFromDocument(doc), nil
+}
+
+// getImagesFromHTML parses a page and resolves every img[src]
+func getImagesFromHTML(htmlBody string, pageURL *url.URL) ([]string, error) {
+	doc, err := parseDocument(htmlBody, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	return imagesFromDocument(doc), nil
+}
+
+// getCanonicalFromHTML returns the absolute URL of the first <link rel="canonical">, or "" when there is none
+func getCanonicalFromHTML(htmlBody string, pageURL *url.URL) string {
+	doc, err := parseDocument(htmlBody, pageURL)
+	if err != nil {
+		return ""
+	}
+	return canonicalFromDocument(doc)
+}
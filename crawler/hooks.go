@@ -0,0 +1,75 @@
+package crawler
+
+import "net/http"
+
+// RequestHook may modify an outgoing request, e.g. to sign it. Returning an error skips the page.
+type RequestHook func(req *http.Request) error
+
+// ResponseHook inspects a response before its body is read. Returning an error skips the page.
+type ResponseHook func(resp *http.Response) error
+
+// PageHook may modify extracted page data. Returning false drops the page from the results.
+type PageHook func(page *PageData) (keep bool)
+
+// LinkHook decides whether a link discovered on a page is queued for crawling
+type LinkHook func(from PageData, link string) (follow bool)
+
+// hooks is the pipeline of user hooks run around each page, in the order they were added
+type hooks struct {
+	onRequest  []RequestHook
+	onResponse []ResponseHook
+	onPage     []PageHook
+	onLink     []LinkHook
+}
+
+// request runs every RequestHook, stopping at the first error
+func (h *hooks) request(req *http.Request) error {
+	if h == nil {
+		return nil
+	}
+	for _, hook := range h.onRequest {
+		if err := hook(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// response runs every ResponseHook, stopping at the first error
+func (h *hooks) response(resp *http.Response) error {
+	if h == nil {
+		return nil
+	}
+	for _, hook := range h.onResponse {
+		if err := hook(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// page runs every PageHook, stopping as soon as one drops the page
+func (h *hooks) page(page *PageData) (keep bool) {
+	if h == nil {
+		return true
+	}
+	for _, hook := range h.onPage {
+		if !hook(page) {
+			return false
+		}
+	}
+	return true
+}
+
+// link runs every LinkHook, stopping as soon as one rejects the link
+func (h *hooks) link(from PageData, link string) (follow bool) {
+	if h == nil {
+		return true
+	}
+	for _, hook := range h.onLink {
+		if !hook(from, link) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCrawlerHooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only signed requests get real content
+		if r.Header.Get("X-Signature") != "signed" {
+			http.Error(w, "unsigned", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("X-Page", r.URL.Path)
+		fmt.Fprintf(w, `<html><body><h1>%s</h1>
+			<a href="/keep">keep</a>
+			<a href="/drop">drop</a>
+			<a href="/vetoed">vetoed</a>
+			<a href="/blocked">blocked</a>
+		</body></html>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	responses := []string{}
+
+	c, err := New([]Seed{{URL: server.URL}},
+		WithOnRequest(func(req *http.Request) error {
+			if strings.HasSuffix(req.URL.Path, "/blocked") {
+				return errors.New("blocked by policy")
+			}
+			req.Header.Set("X-Signature", "signed")
+			return nil
+		}),
+		WithOnResponse(func(resp *http.Response) error {
+			mu.Lock()
+			defer mu.Unlock()
+			responses = append(responses, resp.Header.Get("X-Page"))
+			return nil
+		}),
+		WithOnPage(func(page *PageData) bool {
+			page.H1 = strings.ToUpper(page.H1)
+			return !strings.HasSuffix(page.URL, "/drop")
+		}),
+		WithOnLink(func(from PageData, link string) bool {
+			return !strings.HasSuffix(link, "/vetoed")
+		}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The seed and /keep are kept, /drop is fetched but dropped, /vetoed is never queued,
+	// and /blocked is rejected by the request hook
+	pages := result.Sites[0].Pages
+	if len(pages) != 2 {
+		t.Fatalf("expected 2 pages, got %d: %v", len(pages), pages)
+	}
+	for _, page := range pages {
+		if page.H1 != strings.ToUpper(page.H1) {
+			t.Errorf("expected page hook to upper-case H1, got %q", page.H1)
+		}
+		if strings.HasSuffix(page.URL, "/vetoed") || strings.HasSuffix(page.URL, "/drop") {
+			t.Errorf("unexpected page in results: %s", page.URL)
+		}
+	}
+
+	if len(responses) != 3 {
+		t.Errorf("expected 3 responses to be inspected, got %v", responses)
+	}
+}
+
+func TestNilHooks(t *testing.T) {
+	var h *hooks
+
+	if err := h.request(nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := h.response(nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !h.page(&PageData{}) {
+		t.Error("expected nil hooks to keep pages")
+	}
+	if !h.link(PageData{}, "https://example.com") {
+		t.Error("expected nil hooks to follow links")
+	}
+}
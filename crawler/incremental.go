@@ -0,0 +1,134 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// How a page differs from the previous crawl in an incremental crawl
+const (
+	ChangeNew     = "new"     // Not in the previous crawl
+	ChangeChanged = "changed" // Main content differs from the previous crawl
+	ChangeRemoved = "removed" // In the previous crawl but not reached this time
+)
+
+// WriteState saves every crawled page as JSON Lines, to be read back with ReadState for the next incremental crawl
+func WriteState(w io.Writer, result Result) error {
+	encoder := json.NewEncoder(w)
+	for _, site := range result.Sites {
+		normalizedURLs := make([]string, 0, len(site.Pages))
+		for normalizedURL := range site.Pages {
+			normalizedURLs = append(normalizedURLs, normalizedURL)
+		}
+		sort.Strings(normalizedURLs)
+
+		for _, normalizedURL := range normalizedURLs {
+			page := PageResult{Site: site.Site, NormalizedURL: normalizedURL, Page: site.Pages[normalizedURL]}
+			if err := encoder.Encode(page); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ReadState reads pages saved by WriteState
+func ReadState(r io.Reader) ([]PageResult, error) {
+	pages := []PageResult{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // Pages with many links make long lines
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var page PageResult
+		if err := json.Unmarshal(scanner.Bytes(), &page); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		pages = append(pages, page)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// loadSitemap fetches the site's sitemap lastmod dates so unchanged pages can skip their request
+func (cfg *config) loadSitemap() {
+	lastMods, err := getSitemapLastMods(cfg.baseURL, cfg.hooks)
+	if err != nil {
+		cfg.logf("no usable sitemap for %s, falling back to conditional requests: %v\n", cfg.site, err)
+		return
+	}
+
+	// Key lastmods the same way pages are keyed
+	cfg.sitemapLastMods = make(map[string]time.Time, len(lastMods))
+	for rawURL, lastMod := range lastMods {
+		if normalizedURL, err := cfg.normalization.normalize(rawURL); err == nil {
+			cfg.sitemapLastMods[normalizedURL] = lastMod
+		}
+	}
+}
+
+// unchangedInSitemap reports whether the sitemap says a page hasn't changed since it was last crawled
+func (cfg *config) unchangedInSitemap(normalizedURL string, previous PageData) bool {
+	lastMod, exists := cfg.sitemapLastMods[normalizedURL]
+	return exists && !previous.CrawledAt.IsZero() && !lastMod.After(previous.CrawledAt)
+}
+
+// carryOver reuses a page from the previous crawl, following its links as if it had just been fetched
+func (cfg *config) carryOver(normalizedURL string, depth int, previous PageData) {
+	previous.DuplicateOf = "" // Recomputed against this crawl's pages
+	cfg.processPage(normalizedURL, depth, previous, previous.OutgoingLinks)
+}
+
+// recordChange compares a page with the previous crawl, if this crawl is incremental
+func (cfg *config) recordChange(normalizedURL string, pageData PageData) {
+	if cfg.previous == nil {
+		return
+	}
+	previous, existed := cfg.previous[normalizedURL]
+
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	if cfg.changes == nil {
+		cfg.changes = make(map[string]string)
+	}
+	switch {
+	case existed && previous.ContentHash == pageData.ContentHash:
+		cfg.unchanged++
+	case !cfg.roomForRecord(len(cfg.changes)):
+	case !existed:
+		cfg.changes[normalizedURL] = ChangeNew
+	default:
+		cfg.changes[normalizedURL] = ChangeChanged
+	}
+}
+
+// siteChanges lists every change since the previous crawl, or nil if the crawl isn't incremental.
+// Pages are only reported removed after a complete crawl that kept its pages in memory. Callers hold cfg.mu.
+func (cfg *config) siteChanges(partial bool) map[string]string {
+	if cfg.previous == nil {
+		return nil
+	}
+
+	changes := make(map[string]string, len(cfg.changes))
+	for normalizedURL, change := range cfg.changes {
+		changes[normalizedURL] = change
+	}
+	if partial || cfg.stream != nil {
+		return changes
+	}
+	for normalizedURL := range cfg.previous {
+		if _, exists := cfg.pages[normalizedURL]; !exists {
+			changes[normalizedURL] = ChangeRemoved
+		}
+	}
+	return changes
+}
@@ -0,0 +1,138 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestIncrementalCrawl(t *testing.T) {
+	mu := &sync.Mutex{}
+	requests := map[string]int{}
+	homeLinks, cContent := `<a href="/a">a</a><a href="/b">b</a><a href="/c">c</a><a href="/gone">gone</a>`, "old"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		links, content := homeLinks, cContent
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprint(w, `<urlset><url><loc>http://`+r.Host+`/a</loc><lastmod>2000-01-01</lastmod></url></urlset>`)
+			return
+		case "/b":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+		case "/gone":
+			if !strings.Contains(links, "/gone") {
+				http.NotFound(w, r)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "text/html")
+		body := "page " + r.URL.Path
+		if r.URL.Path == "/" {
+			body = links
+		} else if r.URL.Path == "/c" {
+			body = content
+		}
+		fmt.Fprintf(w, `<html><body><p>%s</p></body></html>`, body)
+	}))
+	defer server.Close()
+
+	first, err := New([]Seed{{URL: server.URL}}, WithMaxPages(20))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	firstResult, err := first.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if firstResult.Sites[0].Changes != nil {
+		t.Errorf("expected no changes outside incremental crawls, got %v", firstResult.Sites[0].Changes)
+	}
+
+	// Round-trip the first crawl through its saved state
+	var state bytes.Buffer
+	if err := WriteState(&state, firstResult); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	previous, err := ReadState(&state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(previous) != 5 {
+		t.Fatalf("expected 5 saved pages, got %d", len(previous))
+	}
+
+	// The site changes between crawls
+	mu.Lock()
+	homeLinks, cContent = `<a href="/a">a</a><a href="/b">b</a><a href="/c">c</a><a href="/new">new</a>`, "new"
+	clear(requests)
+	mu.Unlock()
+
+	second, err := New([]Seed{{URL: server.URL}}, WithMaxPages(20), WithPreviousResults(previous))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := second.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	site := result.Sites[0]
+	host := strings.TrimPrefix(server.URL, "http://")
+	expected := map[string]string{
+		host:           ChangeChanged,
+		host + "/c":    ChangeChanged,
+		host + "/new":  ChangeNew,
+		host + "/gone": ChangeRemoved,
+	}
+	if !reflect.DeepEqual(site.Changes, expected) {
+		t.Errorf("expected changes %v, got %v", expected, site.Changes)
+	}
+	if site.Unchanged != 2 {
+		t.Errorf("expected 2 unchanged pages, got %d", site.Unchanged)
+	}
+	if len(site.Pages) != 5 {
+		t.Errorf("expected a merged report of 5 pages, got %d", len(site.Pages))
+	}
+	if requests["/a"] != 0 {
+		t.Errorf("expected /a to be skipped by its sitemap lastmod, got %d requests", requests["/a"])
+	}
+	if page := site.Pages[host+"/b"]; page.ETag != `"v1"` || page.FirstParagraph != "page /b" {
+		t.Errorf("expected /b to be carried over after a 304, got %+v", page)
+	}
+}
+
+func TestRecordChangeMaxRecords(t *testing.T) {
+	cfg := &config{
+		mu:         &sync.Mutex{},
+		previous:   map[string]PageData{"example.com/a": {ContentHash: "a"}, "example.com/b": {ContentHash: "b"}},
+		maxRecords: 1,
+	}
+	cfg.recordChange("example.com/a", PageData{ContentHash: "a2"})
+	cfg.recordChange("example.com/b", PageData{ContentHash: "b"})
+	cfg.recordChange("example.com/c", PageData{ContentHash: "c"})
+
+	// Unchanged pages are only counted, so just the new page is dropped
+	if expected := map[string]string{"example.com/a": ChangeChanged}; !reflect.DeepEqual(cfg.changes, expected) {
+		t.Errorf("expected changes %v, got %v", expected, cfg.changes)
+	}
+	if cfg.unchanged != 1 {
+		t.Errorf("expected 1 unchanged page, got %d", cfg.unchanged)
+	}
+	if cfg.recordsDropped != 1 {
+		t.Errorf("expected 1 dropped record, got %d", cfg.recordsDropped)
+	}
+}
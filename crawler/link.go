@@ -0,0 +1,82 @@
+package crawler
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Link is a link or embed from a page to another page, with the attributes of its element
+type Link struct {
+	URL       string   `json:"url"`
+	Text      string   `json:"text,omitempty"`     // Anchor text with whitespace collapsed, or the alt text of a linked image
+	Title     string   `json:"title,omitempty"`    // title attribute
+	Rel       []string `json:"rel,omitempty"`      // Lower-cased rel values, e.g. nofollow, sponsored, ugc or noopener
+	Target    string   `json:"target,omitempty"`   // Browsing context, e.g. _blank
+	Hreflang  string   `json:"hreflang,omitempty"` // Language of the linked page
+	Element   string   `json:"element"`            // e.g. "a", "area", "iframe" or "link"
+	Attribute string   `json:"attribute"`          // "href" or "src"
+	Type      string   `json:"type,omitempty"`     // Link type of an a[href] or area[href], e.g. navigational or fragment
+}
+
+// HasRel reports whether the link has a rel value, ignoring case
+func (l Link) HasRel(value string) bool {
+	for _, rel := range l.Rel {
+		if strings.EqualFold(rel, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// newLink reads a link's attributes from the element it was found in
+func newLink(s *goquery.Selection, resolvedURL, attribute string) Link {
+	title, _ := s.Attr("title")
+	rel, _ := s.Attr("rel")
+	target, _ := s.Attr("target")
+	hreflang, _ := s.Attr("hreflang")
+
+	link := Link{
+		URL:       resolvedURL,
+		Text:      linkText(s),
+		Title:     strings.TrimSpace(title),
+		Target:    strings.TrimSpace(target),
+		Hreflang:  strings.TrimSpace(hreflang),
+		Element:   goquery.NodeName(s),
+		Attribute: attribute,
+	}
+	if relValues := strings.Fields(strings.ToLower(rel)); len(relValues) > 0 {
+		link.Rel = relValues
+	}
+	return link
+}
+
+// linkText returns an element's text with whitespace collapsed, falling back to its alt text
+// (for <area>) or the alt text of an image inside it
+func linkText(s *goquery.Selection) string {
+	if text := strings.Join(strings.Fields(s.Text()), " "); text != "" {
+		return text
+	}
+	if alt, exists := s.Attr("alt"); exists {
+		return strings.TrimSpace(alt)
+	}
+	alt, _ := s.Find("img[alt]").First().Attr("alt")
+	return strings.TrimSpace(alt)
+}
+
+// nofollowURLs returns the URLs a page only links to with rel=nofollow
+func nofollowURLs(links []Link) map[string]bool {
+	nofollow := map[string]bool{}
+	followed := map[string]bool{}
+	for _, link := range links {
+		if link.HasRel("nofollow") {
+			nofollow[link.URL] = true
+		} else {
+			followed[link.URL] = true
+		}
+	}
+	for linkURL := range followed {
+		delete(nofollow, linkURL)
+	}
+	return nofollow
+}
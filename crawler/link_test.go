@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestExtractPageDataLinkRecords(t *testing.T) {
+	inputBody := `<html><body>
+		<a href="/docs" title="Documentation" target="_blank" rel="NoOpener  noreferrer">
+			Read   the
+			docs
+		</a>
+		<a href="https://partner.example/" rel="sponsored nofollow" hreflang="de">Partner</a>
+		<a href="/home"><img src="/logo.png" alt="Home"></a>
+		<map><area href="/region" alt="Region"></map>
+	</body></html>`
+
+	actual := extractPageData(inputBody, "https://example.com/")
+
+	expected := []Link{
+		{URL: "https://example.com/docs", Text: "Read the docs", Title: "Documentation", Rel: []string{"noopener", "noreferrer"}, Target: "_blank", Element: "a", Attribute: "href", Type: LinkNavigational},
+		{URL: "https://partner.example/", Text: "Partner", Rel: []string{"sponsored", "nofollow"}, Hreflang: "de", Element: "a", Attribute: "href", Type: LinkNavigational},
+		{URL: "https://example.com/home", Text: "Home", Element: "a", Attribute: "href", Type: LinkNavigational},
+		{URL: "https://example.com/region", Text: "Region", Element: "area", Attribute: "href", Type: LinkNavigational},
+	}
+	if !reflect.DeepEqual(actual.Links, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual.Links)
+	}
+
+	// The compact form keeps only the URLs
+	expectedURLs := []string{"https://example.com/docs", "https://partner.example/", "https://example.com/home", "https://example.com/region"}
+	if !reflect.DeepEqual(actual.OutgoingLinks, expectedURLs) {
+		t.Errorf("expected %v, got %v", expectedURLs, actual.OutgoingLinks)
+	}
+}
+
+func TestNofollowURLs(t *testing.T) {
+	links := []Link{
+		{URL: "https://example.com/a", Rel: []string{"nofollow"}},
+		{URL: "https://example.com/b", Rel: []string{"ugc", "nofollow"}},
+		{URL: "https://example.com/b"},
+		{URL: "https://example.com/c"},
+	}
+
+	expected := map[string]bool{"https://example.com/a": true}
+	if actual := nofollowURLs(links); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestCrawlerRunSkipNofollow(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><h1>Page %s</h1><a href="/a">a</a><a href="/login" rel="nofollow">login</a></body></html>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		skipNofollow bool
+		expected     int
+	}{
+		{"nofollow followed by default", false, 3},
+		{"nofollow skipped", true, 2},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := New([]Seed{{URL: server.URL}}, WithSkipNofollow(tc.skipNofollow))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			result, err := c.Run(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if pages := len(result.Sites[0].Pages); pages != tc.expected {
+				t.Errorf("expected %d pages, got %d", tc.expected, pages)
+			}
+			if tc.skipNofollow && result.Stats.Skipped[skipNofollow] == 0 {
+				t.Errorf("expected nofollow skips, got %v", result.Stats.Skipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package crawler
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+// DefaultNearDuplicateDistance is the largest SimHash Hamming distance treated as near-duplicate
+const DefaultNearDuplicateDistance = 3
+
+// simHashShingleSize is the number of words in each SimHash feature
+const simHashShingleSize = 3
+
+// simHash computes a 64-bit SimHash fingerprint of text, or 0 when there is no text
+func simHash(text string) uint64 {
+	words := strings.Fields(strings.ToLower(text))
+	if len(words) == 0 {
+		return 0
+	}
+
+	// Use overlapping word shingles as features so word order matters
+	features := []string{}
+	if len(words) < simHashShingleSize {
+		features = append(features, strings.Join(words, " "))
+	}
+	for i := 0; i+simHashShingleSize <= len(words); i++ {
+		features = append(features, strings.Join(words[i:i+simHashShingleSize], " "))
+	}
+
+	// Each feature votes on every bit of the fingerprint
+	var votes [64]int
+	for _, feature := range features {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				votes[bit]++
+			} else {
+				votes[bit]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for bit := 0; bit < 64; bit++ {
+		if votes[bit] > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	return fingerprint
+}
+
+// hammingDistance counts the bits that differ between two fingerprints
+func hammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// nearDuplicateMember is one page in a near-duplicate group
+type nearDuplicateMember struct {
+	url        string
+	distance   int     // Hamming distance to the group's representative
+	similarity float64 // 1 - distance/64
+}
+
+// nearDuplicateGroup is a representative page and the pages within the distance threshold of it
+type nearDuplicateGroup struct {
+	representativeURL string
+	members           []nearDuplicateMember
+}
+
+// simHashBands splits fingerprints into maxDistance+1 bit ranges. Two fingerprints at most maxDistance
+// bits apart must agree exactly on at least one range, so only pages sharing a range value are compared.
+func simHashBands(maxDistance int) []uint64 {
+	if maxDistance >= 63 {
+		return []uint64{0} // Every pair is close enough to compare
+	}
+	bands := maxDistance + 1
+	masks := make([]uint64, bands)
+	for band := range bands {
+		low, high := band*64/bands, (band+1)*64/bands
+		masks[band] = (^uint64(0) >> (64 - (high - low))) << low
+	}
+	return masks
+}
+
+// findNearDuplicateGroups groups pages whose SimHash fingerprints are within maxDistance bits of a
+// representative page. Groups don't chain: if A~B and B~C but C is too far from A, C isn't in A's group.
+func findNearDuplicateGroups(pages map[string]PageData, maxDistance int) []nearDuplicateGroup {
+	if maxDistance < 0 {
+		return nil
+	}
+
+	// Exact duplicates are reported separately, and empty pages have nothing to compare
+	candidates := []PageData{}
+	for _, pageData := range pages {
+		if pageData.SimHash != 0 && pageData.DuplicateOf == "" {
+			candidates = append(candidates, pageData)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].URL < candidates[j].URL
+	})
+
+	// Candidates are sorted, so a page joins the closest earlier representative within the threshold,
+	// or becomes a representative itself. Representatives are indexed by each band of their fingerprint.
+	masks := simHashBands(maxDistance)
+	index := make([]map[uint64][]int, len(masks))
+	for band := range index {
+		index[band] = make(map[uint64][]int)
+	}
+	groups := []nearDuplicateGroup{}
+	representatives := []uint64{}
+	for _, pageData := range candidates {
+		best, bestDistance := -1, maxDistance+1
+		for band, mask := range masks {
+			for _, group := range index[band][pageData.SimHash&mask] {
+				distance := hammingDistance(representatives[group], pageData.SimHash)
+				if distance < bestDistance || (distance == bestDistance && group < best) {
+					best, bestDistance = group, distance
+				}
+			}
+		}
+
+		if best >= 0 {
+			groups[best].members = append(groups[best].members, nearDuplicateMember{
+				url:        pageData.URL,
+				distance:   bestDistance,
+				similarity: 1 - float64(bestDistance)/64,
+			})
+			continue
+		}
+
+		group := len(groups)
+		groups = append(groups, nearDuplicateGroup{representativeURL: pageData.URL})
+		representatives = append(representatives, pageData.SimHash)
+		for band, mask := range masks {
+			key := pageData.SimHash & mask
+			index[band][key] = append(index[band][key], group)
+		}
+	}
+
+	// Only keep groups that actually contain near-duplicates
+	nearDuplicates := []nearDuplicateGroup{}
+	for _, group := range groups {
+		if len(group.members) > 0 {
+			nearDuplicates = append(nearDuplicates, group)
+		}
+	}
+	return nearDuplicates
+}
@@ -0,0 +1,130 @@
+package crawler
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestSimHashSimilarText(t *testing.T) {
+	base := "Our weekly community update covers new courses, bug fixes, leaderboard changes and upcoming events for every learner on the platform this season"
+	templated := "Our weekly community update covers new courses, bug fixes, leaderboard changes and upcoming events for every learner on the platform this autumn"
+	unrelated := "Golang channels let goroutines communicate safely by passing values instead of sharing memory through locks and condition variables"
+
+	similar := hammingDistance(simHash(base), simHash(templated))
+	different := hammingDistance(simHash(base), simHash(unrelated))
+
+	if similar >= different {
+		t.Errorf("expected templated text (%d bits) to be closer than unrelated text (%d bits)", similar, different)
+	}
+	if simHash(base) != simHash(base) {
+		t.Error("expected simHash to be deterministic")
+	}
+	if simHash("") != 0 {
+		t.Error("expected empty text to have a zero fingerprint")
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	if d := hammingDistance(0b1011, 0b0010); d != 2 {
+		t.Errorf("expected distance 2, got %d", d)
+	}
+}
+
+func TestFindNearDuplicateGroups(t *testing.T) {
+	pages := map[string]PageData{
+		"example.com/tag/a": {URL: "https://example.com/tag/a", SimHash: 0xFF00},
+		"example.com/tag/b": {URL: "https://example.com/tag/b", SimHash: 0xFF01},
+		"example.com/tag/c": {URL: "https://example.com/tag/c", SimHash: 0xFF03},
+		"example.com/other": {URL: "https://example.com/other", SimHash: 0x00FF},
+		"example.com/exact": {URL: "https://example.com/exact", SimHash: 0xFF00, DuplicateOf: "https://example.com/tag/a"},
+		"example.com/empty": {URL: "https://example.com/empty"},
+	}
+
+	groups := findNearDuplicateGroups(pages, 2)
+	if len(groups) != 1 {
+		t.Fatalf("expected 1 group, got %+v", groups)
+	}
+
+	group := groups[0]
+	if group.representativeURL != "https://example.com/tag/a" {
+		t.Errorf("expected tag/a as representative, got %s", group.representativeURL)
+	}
+	if len(group.members) != 2 {
+		t.Fatalf("expected 2 members, got %+v", group.members)
+	}
+	if group.members[0].url != "https://example.com/tag/b" || group.members[0].distance != 1 {
+		t.Errorf("unexpected first member %+v", group.members[0])
+	}
+	if group.members[1].url != "https://example.com/tag/c" || group.members[1].similarity != 1-2.0/64 {
+		t.Errorf("unexpected second member %+v", group.members[1])
+	}
+}
+
+func TestFindNearDuplicateGroupsDoesNotChain(t *testing.T) {
+	// b is 2 bits from a and c is 2 bits from b, but c is 4 bits from a
+	pages := map[string]PageData{
+		"example.com/a": {URL: "https://example.com/a", SimHash: 0xF000},
+		"example.com/b": {URL: "https://example.com/b", SimHash: 0xF003},
+		"example.com/c": {URL: "https://example.com/c", SimHash: 0xF00F},
+	}
+
+	groups := findNearDuplicateGroups(pages, 2)
+	if len(groups) != 1 || len(groups[0].members) != 1 {
+		t.Fatalf("expected one group with one member, got %+v", groups)
+	}
+	if groups[0].members[0].url != "https://example.com/b" {
+		t.Errorf("expected only b in a's group, got %+v", groups[0].members)
+	}
+}
+
+func TestFindNearDuplicateGroupsThreshold(t *testing.T) {
+	// Fingerprints cluster around a few bases, so there are plenty of near-duplicates to find
+	rng := rand.New(rand.NewPCG(1, 2))
+	bases := []uint64{rng.Uint64(), rng.Uint64(), rng.Uint64(), rng.Uint64()}
+	pages := map[string]PageData{}
+	for i := range 500 {
+		fingerprint := bases[i%len(bases)]
+		for range rng.IntN(4) {
+			fingerprint ^= 1 << rng.IntN(64)
+		}
+		url := fmt.Sprintf("https://example.com/%03d", i)
+		pages[url] = PageData{URL: url, SimHash: fingerprint | 1}
+	}
+
+	for _, maxDistance := range []int{0, 3, 6, 64} {
+		groups := findNearDuplicateGroups(pages, maxDistance)
+		for i, group := range groups {
+			representative := pages[group.representativeURL].SimHash
+			for _, member := range group.members {
+				if distance := hammingDistance(representative, pages[member.url].SimHash); distance > maxDistance || distance != member.distance {
+					t.Errorf("distance %d: %s is %d bits from its representative, reported %d", maxDistance, member.url, distance, member.distance)
+				}
+			}
+
+			// A later representative within the threshold of an earlier one would have joined it
+			for _, other := range groups[:i] {
+				if hammingDistance(representative, pages[other.representativeURL].SimHash) <= maxDistance {
+					t.Errorf("distance %d: representatives %s and %s should be one group", maxDistance, other.representativeURL, group.representativeURL)
+				}
+			}
+		}
+		if maxDistance == 64 && (len(groups) != 1 || len(groups[0].members) != len(pages)-1) {
+			t.Errorf("expected every page in one group at distance 64, got %d groups", len(groups))
+		}
+	}
+}
+
+func BenchmarkFindNearDuplicateGroups(b *testing.B) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	pages := map[string]PageData{}
+	for i := range 20000 {
+		url := fmt.Sprintf("https://example.com/%05d", i)
+		pages[url] = PageData{URL: url, SimHash: rng.Uint64() | 1}
+	}
+
+	b.ResetTimer()
+	for range b.N {
+		findNearDuplicateGroups(pages, DefaultNearDuplicateDistance)
+	}
+}
@@ -0,0 +1,311 @@
+package crawler
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// DefaultStripParams are the tracking and session parameters removed from URLs by default.
+// Patterns are globs matched case-insensitively against parameter names.
+var DefaultStripParams = []string{
+	"utm_*", "fbclid", "gclid", "dclid", "gbraid", "wbraid", "msclkid", "yclid", "twclid", "igshid",
+	"mc_cid", "mc_eid", "_ga", "_gl", "_hsenc", "_hsmi", "mkt_tok",
+	"sessionid", "session_id", "phpsessid", "jsessionid", "aspsessionid*", "cfid", "cftoken",
+}
+
+// indexFiles are the directory index documents that name the same page as their directory
+var indexFiles = []string{
+	"index.html", "index.htm", "index.shtml", "index.php", "index.asp", "index.aspx", "index.jsp",
+	"default.html", "default.htm", "default.asp", "default.aspx",
+}
+
+// defaultPorts maps each scheme to the port it implies
+var defaultPorts = map[string]string{"http": "80", "https": "443"}
+
+// URLNormalization controls how URLs are reduced to the keys that identify pages.
+// The zero value leaves the scheme out of the key, applies every RFC 3986 normalization step,
+// keeps the case of paths, treats query parameters as part of a page's identity, sorted by name,
+// and strips DefaultStripParams.
+type URLNormalization struct {
+	IgnoreQuery        bool     // Treat URLs that differ only in their query string as one page
+	IgnoreParams       []string // Glob patterns of parameters left out of page keys but still fetched, e.g. "ref"
+	StripParams        []string // Glob patterns of parameters removed before URLs are queued, on top of the defaults
+	KeepTrackingParams bool     // Don't strip DefaultStripParams
+
+	KeepScheme           bool // Make http:// and https:// URLs separate pages
+	KeepDefaultPort      bool // Don't drop :80 from http:// or :443 from https:// URLs
+	KeepDotSegments      bool // Don't resolve "." and ".." path segments
+	KeepDuplicateSlashes bool // Don't collapse "//" in paths
+	KeepIndexFile        bool // Don't treat /dir/index.html and friends as /dir/
+	KeepTrailingSlash    bool // Make /dir/ and /dir separate pages
+	FoldPathCase         bool // Treat paths that differ only in case as one page, for case-insensitive servers
+}
+
+// StrippedURL records the parameters removed from one discovered URL
+type StrippedURL struct {
+	URL    string   // The URL as queued
+	Params []string // Names of the parameters removed, sorted
+}
+
+func normalizeURL(inputURL string) (string, error) {
+	return URLNormalization{}.normalize(inputURL)
+}
+
+// normalize reduces a URL to the key that identifies its page
+func (n URLNormalization) normalize(inputURL string) (string, error) {
+	parsedURL, err := url.Parse(inputURL)
+	if err != nil {
+		return "", err
+	}
+
+	normalizedURL := n.normalizeHost(parsedURL) + n.normalizePath(parsedURL.EscapedPath())
+	if n.KeepScheme && parsedURL.Scheme != "" {
+		normalizedURL = strings.ToLower(parsedURL.Scheme) + "://" + normalizedURL
+	}
+
+	// Query values are case-sensitive, so only the parameter order is normalized
+	if query := n.normalizeQuery(parsedURL.RawQuery); query != "" {
+		normalizedURL += "?" + query
+	}
+
+	return normalizedURL, nil
+}
+
+// normalizeHost returns the URL's host in lower-case ASCII (punycode) form, without the scheme's default port
+func (n URLNormalization) normalizeHost(parsedURL *url.URL) string {
+	host := asciiHostname(parsedURL.Hostname())
+
+	port := parsedURL.Port()
+	if port != "" && (n.KeepDefaultPort || defaultPorts[strings.ToLower(parsedURL.Scheme)] != port) {
+		return net.JoinHostPort(host, port)
+	}
+	if strings.Contains(host, ":") {
+		return "[" + host + "]" // IPv6 literal
+	}
+	return host
+}
+
+// asciiHostname lower-cases a hostname and converts an internationalized one to punycode
+func asciiHostname(hostname string) string {
+	hostname = strings.ToLower(hostname)
+	if asciiHost, err := idna.Lookup.ToASCII(hostname); err == nil {
+		return asciiHost
+	}
+	return hostname
+}
+
+// normalizePath applies each enabled path normalization step in turn to an escaped path
+func (n URLNormalization) normalizePath(escapedPath string) string {
+	escapedPath = canonicalPercentEncoding(escapedPath, n.FoldPathCase)
+	if !n.KeepDotSegments {
+		escapedPath = removeDotSegments(escapedPath)
+	}
+	if !n.KeepDuplicateSlashes {
+		escapedPath = collapseSlashes(escapedPath)
+	}
+	if !n.KeepIndexFile {
+		escapedPath = trimIndexFile(escapedPath)
+	}
+	if !n.KeepTrailingSlash {
+		escapedPath = strings.TrimSuffix(escapedPath, "/")
+	}
+	return escapedPath
+}
+
+// canonicalPercentEncoding gives every spelling of a path the same encoding (RFC 3986 section 6.2.2.2):
+// unreserved characters are decoded, everything else outside the path character set is encoded
+// as UTF-8 with upper-case hex, and encoded reserved characters such as %2F stay encoded
+func canonicalPercentEncoding(escapedPath string, foldCase bool) string {
+	// Decode escapes that don't change the meaning of the path, so é, %c3%a9 and %C3%A9 all become é
+	var decoded strings.Builder
+	for i := 0; i < len(escapedPath); i++ {
+		if c, ok := percentDecode(escapedPath, i); ok && (isUnreserved(c) || c >= utf8.RuneSelf) {
+			decoded.WriteByte(c)
+			i += 2
+			continue
+		}
+		decoded.WriteByte(escapedPath[i])
+	}
+
+	text := decoded.String()
+	if foldCase && utf8.ValidString(text) {
+		text = strings.ToLower(text) // Hex digits in remaining escapes are upper-cased below
+	}
+
+	var encoded strings.Builder
+	for i := 0; i < len(text); i++ {
+		if c, ok := percentDecode(text, i); ok {
+			fmt.Fprintf(&encoded, "%%%02X", c)
+			i += 2
+			continue
+		}
+		if c := text[i]; isPathChar(c) {
+			encoded.WriteByte(c)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return encoded.String()
+}
+
+// percentDecode decodes the escape at s[i], reporting false if there isn't a valid one
+func percentDecode(s string, i int) (byte, bool) {
+	if i+2 >= len(s) || s[i] != '%' {
+		return 0, false
+	}
+	hi, okHi := unhex(s[i+1])
+	lo, okLo := unhex(s[i+2])
+	return hi<<4 | lo, okHi && okLo
+}
+
+// unhex returns the value of a hex digit
+func unhex(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// isUnreserved reports whether c is an RFC 3986 unreserved character, which never needs encoding
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-._~", c) >= 0
+}
+
+// isPathChar reports whether c may appear unencoded in a URL path
+func isPathChar(c byte) bool {
+	return isUnreserved(c) || strings.IndexByte("!$&'()*+,;=:@/", c) >= 0
+}
+
+// removeDotSegments resolves "." and ".." segments as described in RFC 3986 section 5.2.4
+func removeDotSegments(urlPath string) string {
+	if !strings.Contains(urlPath, ".") {
+		return urlPath
+	}
+
+	segments := strings.Split(urlPath, "/")
+	kept := []string{}
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch segment {
+		case ".":
+		case "..":
+			// The leading empty segment of an absolute path is never removed
+			if len(kept) > 1 || (len(kept) == 1 && kept[0] != "") {
+				kept = kept[:len(kept)-1]
+			}
+		default:
+			kept = append(kept, segment)
+			continue
+		}
+		// A trailing dot segment still names a directory
+		if last {
+			kept = append(kept, "")
+		}
+	}
+	return strings.Join(kept, "/")
+}
+
+// collapseSlashes replaces runs of slashes with a single slash
+func collapseSlashes(urlPath string) string {
+	for strings.Contains(urlPath, "//") {
+		urlPath = strings.ReplaceAll(urlPath, "//", "/")
+	}
+	return urlPath
+}
+
+// trimIndexFile reduces a path ending in a directory index document to its directory
+func trimIndexFile(urlPath string) string {
+	dir, file := path.Split(urlPath)
+	for _, index := range indexFiles {
+		if strings.EqualFold(file, index) {
+			return dir
+		}
+	}
+	return urlPath
+}
+
+// normalizeQuery drops ignored and stripped parameters and sorts the rest by name
+func (n URLNormalization) normalizeQuery(rawQuery string) string {
+	if n.IgnoreQuery || rawQuery == "" {
+		return ""
+	}
+
+	params, _ := url.ParseQuery(rawQuery) // Malformed pairs are dropped
+	stripPatterns := n.stripPatterns()
+	for name := range params {
+		if matchParam(name, n.IgnoreParams) || matchParam(name, stripPatterns) {
+			delete(params, name)
+		}
+	}
+
+	// Encode sorts by parameter name and keeps repeated values in their original order
+	return params.Encode()
+}
+
+// stripParams removes tracking and session parameters from a URL before it is queued,
+// returning the cleaned URL and the sorted names of the parameters removed
+func (n URLNormalization) stripParams(rawURL string) (string, []string) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || parsedURL.RawQuery == "" {
+		return rawURL, nil
+	}
+
+	// Filter the raw pairs so the parameters we keep are untouched
+	stripPatterns := n.stripPatterns()
+	kept := []string{}
+	removed := map[string]bool{}
+	for _, pair := range strings.Split(parsedURL.RawQuery, "&") {
+		rawName, _, _ := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(rawName)
+		if err != nil {
+			name = rawName
+		}
+		if matchParam(name, stripPatterns) {
+			removed[name] = true
+			continue
+		}
+		kept = append(kept, pair)
+	}
+	if len(removed) == 0 {
+		return rawURL, nil
+	}
+
+	parsedURL.RawQuery = strings.Join(kept, "&")
+	names := make([]string, 0, len(removed))
+	for name := range removed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return parsedURL.String(), names
+}
+
+// stripPatterns returns every glob pattern of parameters to strip
+func (n URLNormalization) stripPatterns() []string {
+	if n.KeepTrackingParams {
+		return n.StripParams
+	}
+	return append(append([]string{}, DefaultStripParams...), n.StripParams...)
+}
+
+// matchParam reports whether a parameter name matches any of the glob patterns, ignoring case
+func matchParam(name string, patterns []string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToLower(pattern), name); matched {
+			return true
+		}
+	}
+	return false
+}
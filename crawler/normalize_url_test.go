@@ -0,0 +1,336 @@
+package crawler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		inputURL string
+		expected string
+	}{
+		{
+			name:     "remove scheme",
+			inputURL: "https://blog.boot.dev/path",
+			expected: "blog.boot.dev/path",
+		},
+
+		{
+			name:     "remove scheme",
+			inputURL: "https://blog.boot.dev/path/",
+			expected: "blog.boot.dev/path",
+		},
+
+		{
+			name:     "remove scheme",
+			inputURL: "http://blog.boot.dev/path",
+			expected: "blog.boot.dev/path",
+		},
+
+		{
+			name:     "remove scheme",
+			inputURL: "http://blog.boot.dev/path/",
+			expected: "blog.boot.dev/path",
+		},
+
+		{
+			name:     "no scheme",
+			inputURL: "blog.boot.dev/path",
+			expected: "blog.boot.dev/path",
+		},
+
+		{
+			name:     "no scheme, trailing slash",
+			inputURL: "blog.boot.dev/path/",
+			expected: "blog.boot.dev/path",
+		},
+		// add more test cases here
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := normalizeURL(tc.inputURL)
+			if err != nil {
+				t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+				return
+			}
+			if actual != tc.expected {
+				t.Errorf("Test %v - %s FAIL: expected URL: %v, actual: %v", i, tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNormalizeURLQuery(t *testing.T) {
+	tests := []struct {
+		name          string
+		normalization URLNormalization
+		inputURL      string
+		expected      string
+	}{
+		{
+			name:     "query is part of the page",
+			inputURL: "https://blog.boot.dev/search?page=2",
+			expected: "blog.boot.dev/search?page=2",
+		},
+		{
+			name:     "parameters sorted by name",
+			inputURL: "https://blog.boot.dev/search?q=go&page=2",
+			expected: "blog.boot.dev/search?page=2&q=go",
+		},
+		{
+			name:          "query values keep their case",
+			normalization: URLNormalization{FoldPathCase: true},
+			inputURL:      "https://Blog.Boot.dev/Search?q=GoLang",
+			expected:      "blog.boot.dev/search?q=GoLang",
+		},
+		{
+			name:     "repeated values keep their order",
+			inputURL: "https://blog.boot.dev/search?tag=b&tag=a",
+			expected: "blog.boot.dev/search?tag=b&tag=a",
+		},
+		{
+			name:     "empty query",
+			inputURL: "https://blog.boot.dev/search?",
+			expected: "blog.boot.dev/search",
+		},
+		{
+			name:          "ignore all queries",
+			normalization: URLNormalization{IgnoreQuery: true},
+			inputURL:      "https://blog.boot.dev/search?page=2",
+			expected:      "blog.boot.dev/search",
+		},
+		{
+			name:          "ignore specific parameters",
+			normalization: URLNormalization{IgnoreParams: []string{"ref", "sort"}},
+			inputURL:      "https://blog.boot.dev/search?sort=asc&page=2&ref=nav",
+			expected:      "blog.boot.dev/search?page=2",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := tc.normalization.normalize(tc.inputURL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestStripParams(t *testing.T) {
+	tests := []struct {
+		name          string
+		normalization URLNormalization
+		inputURL      string
+		expectedURL   string
+		expectedNames []string
+	}{
+		{
+			name:          "default tracking parameters",
+			inputURL:      "https://blog.boot.dev/post?utm_source=x&id=7&utm_medium=y&fbclid=abc",
+			expectedURL:   "https://blog.boot.dev/post?id=7",
+			expectedNames: []string{"fbclid", "utm_medium", "utm_source"},
+		},
+		{
+			name:          "session parameters ignore case",
+			inputURL:      "https://blog.boot.dev/post?PHPSESSID=123",
+			expectedURL:   "https://blog.boot.dev/post",
+			expectedNames: []string{"PHPSESSID"},
+		},
+		{
+			name:        "kept parameters are untouched",
+			inputURL:    "https://blog.boot.dev/search?q=a%20b&page=2#top",
+			expectedURL: "https://blog.boot.dev/search?q=a%20b&page=2#top",
+		},
+		{
+			name:          "custom glob patterns",
+			normalization: URLNormalization{StripParams: []string{"ref_*"}},
+			inputURL:      "https://blog.boot.dev/post?ref_src=nav&id=7",
+			expectedURL:   "https://blog.boot.dev/post?id=7",
+			expectedNames: []string{"ref_src"},
+		},
+		{
+			name:          "defaults disabled",
+			normalization: URLNormalization{KeepTrackingParams: true},
+			inputURL:      "https://blog.boot.dev/post?utm_source=x",
+			expectedURL:   "https://blog.boot.dev/post?utm_source=x",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actualURL, actualNames := tc.normalization.stripParams(tc.inputURL)
+			if actualURL != tc.expectedURL {
+				t.Errorf("expected %v, got %v", tc.expectedURL, actualURL)
+			}
+			if !reflect.DeepEqual(actualNames, tc.expectedNames) {
+				t.Errorf("expected stripped %v, got %v", tc.expectedNames, actualNames)
+			}
+		})
+	}
+}
+
+func TestNormalizeURLStripsTrackingParams(t *testing.T) {
+	actual, err := normalizeURL("https://blog.boot.dev/post?id=7&utm_campaign=launch&gclid=x")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "blog.boot.dev/post?id=7"; actual != expected {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestNormalizeURLSteps(t *testing.T) {
+	tests := []struct {
+		name          string
+		normalization URLNormalization
+		inputURL      string
+		expected      string
+	}{
+		{
+			name:     "default http port",
+			inputURL: "http://blog.boot.dev:80/path",
+			expected: "blog.boot.dev/path",
+		},
+		{
+			name:     "default https port",
+			inputURL: "https://blog.boot.dev:443/path",
+			expected: "blog.boot.dev/path",
+		},
+		{
+			name:     "other ports are kept",
+			inputURL: "https://blog.boot.dev:8443/path",
+			expected: "blog.boot.dev:8443/path",
+		},
+		{
+			name:     "dot segments",
+			inputURL: "https://blog.boot.dev/a/./b/../c",
+			expected: "blog.boot.dev/a/c",
+		},
+		{
+			name:     "dot segments above the root",
+			inputURL: "https://blog.boot.dev/../../a",
+			expected: "blog.boot.dev/a",
+		},
+		{
+			name:     "duplicate slashes",
+			inputURL: "https://blog.boot.dev//a///b/",
+			expected: "blog.boot.dev/a/b",
+		},
+		{
+			name:     "index file",
+			inputURL: "https://blog.boot.dev/docs/Index.HTML",
+			expected: "blog.boot.dev/docs",
+		},
+		{
+			name:     "root index file",
+			inputURL: "https://blog.boot.dev/index.php?page=2",
+			expected: "blog.boot.dev?page=2",
+		},
+		{
+			name:     "path case is kept",
+			inputURL: "https://Blog.Boot.dev/Docs/README",
+			expected: "blog.boot.dev/Docs/README",
+		},
+		{
+			name:          "fold path case",
+			normalization: URLNormalization{FoldPathCase: true},
+			inputURL:      "https://blog.boot.dev/Caf%C3%89/README",
+			expected:      "blog.boot.dev/caf%C3%A9/readme",
+		},
+		{
+			name:     "unicode host",
+			inputURL: "https://Bücher.example/path",
+			expected: "xn--bcher-kva.example/path",
+		},
+		{
+			name:     "punycode host",
+			inputURL: "https://XN--BCHER-KVA.example/path",
+			expected: "xn--bcher-kva.example/path",
+		},
+		{
+			name:     "IPv6 host",
+			inputURL: "http://[::1]:80/path",
+			expected: "[::1]/path",
+		},
+		{
+			name:     "encoded unreserved characters",
+			inputURL: "https://blog.boot.dev/%7Euser/a%2Db",
+			expected: "blog.boot.dev/~user/a-b",
+		},
+		{
+			name:     "lower-case escapes",
+			inputURL: "https://blog.boot.dev/caf%c3%a9",
+			expected: "blog.boot.dev/caf%C3%A9",
+		},
+		{
+			name:     "unencoded unicode",
+			inputURL: "https://blog.boot.dev/café",
+			expected: "blog.boot.dev/caf%C3%A9",
+		},
+		{
+			name:     "encoded reserved characters stay encoded",
+			inputURL: "https://blog.boot.dev/a%2fb%3Bc;d",
+			expected: "blog.boot.dev/a%2Fb%3Bc;d",
+		},
+		{
+			name:     "encoded dot segments",
+			inputURL: "https://blog.boot.dev/a/%2E%2E/b",
+			expected: "blog.boot.dev/b",
+		},
+		{
+			name:          "keep scheme",
+			normalization: URLNormalization{KeepScheme: true},
+			inputURL:      "HTTP://blog.boot.dev/path",
+			expected:      "http://blog.boot.dev/path",
+		},
+		{
+			name:          "keep default port",
+			normalization: URLNormalization{KeepDefaultPort: true},
+			inputURL:      "https://blog.boot.dev:443/path",
+			expected:      "blog.boot.dev:443/path",
+		},
+		{
+			name:          "keep dot segments",
+			normalization: URLNormalization{KeepDotSegments: true},
+			inputURL:      "https://blog.boot.dev/a/../b",
+			expected:      "blog.boot.dev/a/../b",
+		},
+		{
+			name:          "keep duplicate slashes",
+			normalization: URLNormalization{KeepDuplicateSlashes: true},
+			inputURL:      "https://blog.boot.dev/a//b",
+			expected:      "blog.boot.dev/a//b",
+		},
+		{
+			name:          "keep index file",
+			normalization: URLNormalization{KeepIndexFile: true},
+			inputURL:      "https://blog.boot.dev/docs/index.html",
+			expected:      "blog.boot.dev/docs/index.html",
+		},
+		{
+			name:          "keep trailing slash",
+			normalization: URLNormalization{KeepTrailingSlash: true},
+			inputURL:      "https://blog.boot.dev/docs/index.html",
+			expected:      "blog.boot.dev/docs/",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := tc.normalization.normalize(tc.inputURL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package crawler
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// DiscoveredURL is a URL found on a page, tagged with the element and attribute it came from
+type DiscoveredURL struct {
+	URL       string `json:"url"`
+	Element   string `json:"element"`             // e.g. "a", "iframe" or "link"
+	Attribute string `json:"attribute,omitempty"` // e.g. "href" or "srcset", empty for <style> content
+}
+
+// urlSource is an element attribute holding URLs, other than a[href] and link[href]
+type urlSource struct {
+	element   string
+	attribute string
+	document  bool // Holds pages to crawl rather than static assets
+	srcset    bool // Holds a srcset candidate list rather than a single URL
+}
+
+var urlSources = []urlSource{
+	{element: "iframe", attribute: "src", document: true},
+	{element: "frame", attribute: "src", document: true},
+	{element: "img", attribute: "src"},
+	{element: "img", attribute: "srcset", srcset: true},
+	{element: "source", attribute: "src"},
+	{element: "source", attribute: "srcset", srcset: true},
+	{element: "script", attribute: "src"},
+	{element: "video", attribute: "poster"},
+	{element: "object", attribute: "data"},
+}
+
+// documentRels and assetRels are the <link rel> types whose href is a page or a static asset
+var (
+	documentRels = map[string]bool{"alternate": true, "next": true, "prev": true, "previous": true}
+	assetRels    = map[string]bool{"stylesheet": true, "icon": true, "apple-touch-icon": true, "mask-icon": true, "manifest": true, "preload": true, "modulepreload": true}
+)
+
+// cssURLPattern matches url() references in CSS, quoted or not
+var cssURLPattern = regexp.MustCompile(`url\(\s*['"]?([^'")]+?)['"]?\s*\)`)
+
+// resourcesFromDocument finds the embedded pages and static assets of a parsed page. Links from
+// a[href] and area[href] are classified separately by linksFromDocument.
+func resourcesFromDocument(doc *document) (documents []Link, assets []DiscoveredURL) {
+	add := func(s *goquery.Selection, rawURL, attribute string, isDocument bool) {
+		resolved, ok := doc.resolveHTTP(rawURL)
+		if !ok {
+			return
+		}
+		if isDocument {
+			documents = append(documents, newLink(s, resolved, attribute))
+		} else {
+			assets = append(assets, DiscoveredURL{URL: resolved, Element: goquery.NodeName(s), Attribute: attribute})
+		}
+	}
+
+	for _, source := range urlSources {
+		doc.Find(source.element + "[" + source.attribute + "]").Each(func(_ int, s *goquery.Selection) {
+			value, _ := s.Attr(source.attribute)
+			if !source.srcset {
+				add(s, value, source.attribute, source.document)
+				return
+			}
+			for _, candidate := range parseSrcset(value) {
+				add(s, candidate, source.attribute, source.document)
+			}
+		})
+	}
+
+	// <link> may point at a page, such as a translation or the next page of a listing, or at an asset
+	doc.Find("link[href][rel]").Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		rel, _ := s.Attr("rel")
+		linkType, _ := s.Attr("type")
+		if isDocument, ok := linkRelKind(rel, linkType); ok {
+			add(s, href, "href", isDocument)
+		}
+	})
+
+	// url() in inline styles and <style> blocks
+	doc.Find("[style]").Each(func(_ int, s *goquery.Selection) {
+		style, _ := s.Attr("style")
+		for _, match := range cssURLPattern.FindAllStringSubmatch(style, -1) {
+			add(s, match[1], "style", false)
+		}
+	})
+	doc.Find("style").Each(func(_ int, s *goquery.Selection) {
+		for _, match := range cssURLPattern.FindAllStringSubmatch(s.Text(), -1) {
+			add(s, match[1], "", false)
+		}
+	})
+
+	return documents, assets
+}
+
+// linkRelKind decides whether a <link> with the given rel and type attributes points at a page or a static
+// asset, reporting false if neither. Asset rels win, so rel="alternate stylesheet" is a stylesheet, and a
+// document rel such as alternate only names a page when its type is empty or HTML, not e.g. an RSS feed.
+func linkRelKind(rel, linkType string) (isDocument, ok bool) {
+	isPage := false
+	for _, relType := range strings.Fields(strings.ToLower(rel)) {
+		if assetRels[relType] {
+			return false, true
+		}
+		isPage = isPage || documentRels[relType]
+	}
+
+	mediaType, _, _ := strings.Cut(linkType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if !isPage || (mediaType != "" && mediaType != "text/html") {
+		return false, false
+	}
+	return true, true
+}
+
+// resolveHTTP resolves a URL from the page against its base URL, reporting false unless it is HTTP(S)
+func (doc *document) resolveHTTP(rawURL string) (string, bool) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return "", false
+	}
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	resolved := doc.baseURL.ResolveReference(parsedURL)
+	if scheme := strings.ToLower(resolved.Scheme); scheme != "http" && scheme != "https" {
+		return "", false
+	}
+	return resolved.String(), true
+}
+
+// parseSrcset returns the URLs of a srcset candidate list, e.g. "a.png 1x, b.png 2x".
+// URLs may contain commas, so each runs to the next whitespace as the HTML spec describes.
+func parseSrcset(srcset string) []string {
+	urls := []string{}
+	rest := srcset
+	for {
+		rest = strings.TrimLeft(rest, " \t\n\r\f,")
+		if rest == "" {
+			return urls
+		}
+
+		end := strings.IndexAny(rest, " \t\n\r\f")
+		if end < 0 {
+			end = len(rest)
+		}
+		candidate := rest[:end]
+		rest = rest[end:]
+
+		// A trailing comma ends the candidate without descriptors
+		if trimmed := strings.TrimRight(candidate, ","); trimmed != candidate {
+			urls = append(urls, trimmed)
+			continue
+		}
+		urls = append(urls, candidate)
+
+		// Skip the descriptors up to the next candidate
+		if next := strings.IndexByte(rest, ','); next >= 0 {
+			rest = rest[next+1:]
+		} else {
+			rest = ""
+		}
+	}
+}
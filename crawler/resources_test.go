@@ -0,0 +1,159 @@
+package crawler
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestResourcesFromDocument(t *testing.T) {
+	inputBody := `<html><head>
+		<link rel="stylesheet" href="/main.css">
+		<link rel="alternate" hreflang="de" href="/de/">
+		<link rel="next" href="?page=2">
+		<link rel="shortcut icon" href="/favicon.ico">
+		<link rel="preconnect" href="https://fonts.example.com">
+		<link rel="alternate stylesheet" href="/dark.css" title="Dark">
+		<link rel="alternate" type="application/rss+xml" href="/feed.xml">
+		<link rel="alternate" type="text/html; charset=utf-8" hreflang="fr" href="/fr/">
+		<script src="/app.js"></script>
+		<script>var inline = true;</script>
+		<style>body { background: url("/bg.png") }</style>
+	</head><body>
+		<iframe src="/embed"></iframe>
+		<iframe src="about:blank"></iframe>
+		<img src="/a.png" srcset="/a-1x.png 1x, /a-2x.png 2x">
+		<picture><source srcset="/b.webp" type="image/webp"></picture>
+		<video poster="/poster.jpg"><source src="/clip.mp4"></video>
+		<object data="/doc.pdf"></object>
+		<div style="background-image: url(hero.jpg)"></div>
+		<img src="data:image/png;base64,AAAA">
+	</body></html>`
+
+	pageURL, err := url.Parse("https://example.com/docs/page")
+	if err != nil {
+		t.Fatalf("couldn't parse input URL: %v", err)
+	}
+	doc, err := parseDocument(inputBody, pageURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	documents, assets := resourcesFromDocument(doc)
+
+	expectedDocuments := []Link{
+		{URL: "https://example.com/embed", Element: "iframe", Attribute: "src"},
+		{URL: "https://example.com/de/", Rel: []string{"alternate"}, Hreflang: "de", Element: "link", Attribute: "href"},
+		{URL: "https://example.com/docs/page?page=2", Rel: []string{"next"}, Element: "link", Attribute: "href"},
+		{URL: "https://example.com/fr/", Rel: []string{"alternate"}, Hreflang: "fr", Element: "link", Attribute: "href"},
+	}
+	if !reflect.DeepEqual(documents, expectedDocuments) {
+		t.Errorf("expected documents %v, got %v", expectedDocuments, documents)
+	}
+
+	expectedAssets := []DiscoveredURL{
+		{URL: "https://example.com/a.png", Element: "img", Attribute: "src"},
+		{URL: "https://example.com/a-1x.png", Element: "img", Attribute: "srcset"},
+		{URL: "https://example.com/a-2x.png", Element: "img", Attribute: "srcset"},
+		{URL: "https://example.com/clip.mp4", Element: "source", Attribute: "src"},
+		{URL: "https://example.com/b.webp", Element: "source", Attribute: "srcset"},
+		{URL: "https://example.com/app.js", Element: "script", Attribute: "src"},
+		{URL: "https://example.com/poster.jpg", Element: "video", Attribute: "poster"},
+		{URL: "https://example.com/doc.pdf", Element: "object", Attribute: "data"},
+		{URL: "https://example.com/main.css", Element: "link", Attribute: "href"},
+		{URL: "https://example.com/favicon.ico", Element: "link", Attribute: "href"},
+		{URL: "https://example.com/dark.css", Element: "link", Attribute: "href"},
+		{URL: "https://example.com/docs/hero.jpg", Element: "div", Attribute: "style"},
+		{URL: "https://example.com/bg.png", Element: "style"},
+	}
+	if !reflect.DeepEqual(assets, expectedAssets) {
+		t.Errorf("expected assets %v, got %v", expectedAssets, assets)
+	}
+}
+
+func TestLinkRelKind(t *testing.T) {
+	tests := []struct {
+		rel        string
+		linkType   string
+		isDocument bool
+		ok         bool
+	}{
+		{"stylesheet", "", false, true},
+		{"alternate stylesheet", "", false, true},
+		{"stylesheet alternate", "text/css", false, true},
+		{"alternate", "", true, true},
+		{"alternate", "text/html", true, true},
+		{"Alternate", "TEXT/HTML; charset=utf-8", true, true},
+		{"alternate", "application/rss+xml", false, false},
+		{"alternate next", "application/atom+xml", false, false},
+		{"next", "", true, true},
+		{"preconnect", "", false, false},
+	}
+	for _, tc := range tests {
+		isDocument, ok := linkRelKind(tc.rel, tc.linkType)
+		if isDocument != tc.isDocument || ok != tc.ok {
+			t.Errorf("rel %q type %q: expected (%v, %v), got (%v, %v)", tc.rel, tc.linkType, tc.isDocument, tc.ok, isDocument, ok)
+		}
+	}
+}
+
+func TestParseSrcset(t *testing.T) {
+	tests := []struct {
+		name     string
+		srcset   string
+		expected []string
+	}{
+		{"density descriptors", "a.png 1x, b.png 2x", []string{"a.png", "b.png"}},
+		{"width descriptors", " a.png 480w,\n b.png 800w ", []string{"a.png", "b.png"}},
+		{"no descriptors", "a.png, b.png", []string{"a.png", "b.png"}},
+		{"comma in URL", "/img?size=1,2 1x, /b.png 2x", []string{"/img?size=1,2", "/b.png"}},
+		{"empty", "", []string{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := parseSrcset(tc.srcset); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGetURLsFromHTMLIncludesEmbeddedDocuments(t *testing.T) {
+	inputBody := `<html><head><link rel="next" href="/list?page=2"><link rel="stylesheet" href="/main.css"></head>
+		<body><a href="/a">a</a><map><area href="/b"></map><iframe src="/c"></iframe><script src="/app.js"></script></body></html>`
+
+	pageURL, err := url.Parse("https://example.com/list")
+	if err != nil {
+		t.Fatalf("couldn't parse input URL: %v", err)
+	}
+	actual, err := getURLsFromHTML(inputBody, pageURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"https://example.com/a", "https://example.com/b", "https://example.com/c", "https://example.com/list?page=2"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestResourcesFromDocumentFrameset(t *testing.T) {
+	inputBody := `<html><frameset cols="50%,50%"><frame src="nav.html"><frame src="/main"></frameset></html>`
+
+	pageURL, err := url.Parse("https://example.com/docs/")
+	if err != nil {
+		t.Fatalf("couldn't parse input URL: %v", err)
+	}
+	doc, err := parseDocument(inputBody, pageURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	documents, _ := resourcesFromDocument(doc)
+
+	expected := []Link{
+		{URL: "https://example.com/docs/nav.html", Element: "frame", Attribute: "src"},
+		{URL: "https://example.com/main", Element: "frame", Attribute: "src"},
+	}
+	if !reflect.DeepEqual(documents, expected) {
+		t.Errorf("expected %v, got %v", expected, documents)
+	}
+}
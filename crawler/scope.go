@@ -0,0 +1,135 @@
+package crawler
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// scopeRules decides which URLs belong to a crawl
+type scopeRules struct {
+	allowedHosts []string         // Exact hosts or "*.example.com" wildcards
+	pathPrefixes []string         // URL path must start with one of these, if any are set
+	seed         string           // Seed URL, crawled even outside pathPrefixes so its links can lead into them
+	include      []*regexp.Regexp // URL must match one of these, if any are set
+	exclude      []*regexp.Regexp // URL must not match any of these
+}
+
+// newScopeRules builds scope rules for a crawl, defaulting to the base URL's host
+func newScopeRules(baseURL *url.URL, hosts, pathPrefixes, include, exclude []string) (*scopeRules, error) {
+	scope := &scopeRules{pathPrefixes: pathPrefixes, seed: baseURL.String()}
+
+	if len(hosts) == 0 {
+		hosts = []string{baseURL.Host}
+	}
+	for _, host := range hosts {
+		scope.allowedHosts = append(scope.allowedHosts, asciiHostPattern(host))
+	}
+
+	for _, pattern := range include {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include pattern %q: %w", pattern, err)
+		}
+		scope.include = append(scope.include, re)
+	}
+
+	for _, pattern := range exclude {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+		scope.exclude = append(scope.exclude, re)
+	}
+
+	return scope, nil
+}
+
+// check reports whether a URL is in scope, and if not, the rule that excluded it
+func (s *scopeRules) check(u *url.URL) (inScope bool, rule string) {
+	if !s.hostAllowed(u) {
+		return false, "host not allowed: " + u.Host
+	}
+
+	if len(s.pathPrefixes) > 0 && u.String() != s.seed {
+		matched := false
+		for _, prefix := range s.pathPrefixes {
+			if strings.HasPrefix(u.Path, prefix) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, "outside path prefixes: " + strings.Join(s.pathPrefixes, ",")
+		}
+	}
+
+	rawURL := u.String()
+	for _, re := range s.exclude {
+		if re.MatchString(rawURL) {
+			return false, "exclude: " + re.String()
+		}
+	}
+
+	if len(s.include) > 0 {
+		for _, re := range s.include {
+			if re.MatchString(rawURL) {
+				return true, ""
+			}
+		}
+		return false, "no include pattern matched"
+	}
+
+	return true, ""
+}
+
+// asciiHostPattern brings an allowed host, wildcard or host:port into the punycode form URLs are normalized to
+func asciiHostPattern(pattern string) string {
+	wildcard := ""
+	if domain, ok := strings.CutPrefix(pattern, "*."); ok {
+		wildcard, pattern = "*.", domain
+	}
+	hostname, port := pattern, ""
+	if h, p, err := net.SplitHostPort(pattern); err == nil {
+		hostname, port = h, p
+	}
+
+	pattern = wildcard + asciiHostname(strings.Trim(hostname, "[]"))
+	if port != "" {
+		pattern += ":" + port
+	}
+	return pattern
+}
+
+// hostAllowed matches a URL's host, in punycode form, against the allowed host list
+func (s *scopeRules) hostAllowed(u *url.URL) bool {
+	hostname := asciiHostname(u.Hostname())
+	host := hostname
+	if port := u.Port(); port != "" {
+		host += ":" + port
+	}
+
+	for _, allowed := range s.allowedHosts {
+		// Compare the port too, but only when the rule mentions one
+		candidate := hostname
+		if strings.Contains(allowed, ":") {
+			candidate = host
+		}
+
+		if domain, ok := strings.CutPrefix(allowed, "*."); ok {
+			// Wildcards match the bare domain and any subdomain of it
+			if candidate == domain || strings.HasSuffix(candidate, "."+domain) {
+				return true
+			}
+			continue
+		}
+
+		if candidate == allowed {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,172 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestScopeRulesCheck(t *testing.T) {
+	baseURL, err := url.Parse("https://www.boot.dev")
+	if err != nil {
+		t.Fatalf("error parsing base URL: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		hosts    []string
+		prefixes []string
+		include  []string
+		exclude  []string
+		inputURL string
+		expected bool
+		rule     string
+	}{
+		{
+			name:     "default scope is the base host",
+			inputURL: "https://www.boot.dev/courses",
+			expected: true,
+		},
+		{
+			name:     "default scope rejects other subdomains",
+			inputURL: "https://blog.boot.dev/",
+			expected: false,
+			rule:     "host not allowed: blog.boot.dev",
+		},
+		{
+			name:     "wildcard subdomain",
+			hosts:    []string{"*.boot.dev"},
+			inputURL: "https://blog.boot.dev/",
+			expected: true,
+		},
+		{
+			name:     "wildcard matches bare domain",
+			hosts:    []string{"*.boot.dev"},
+			inputURL: "https://boot.dev/",
+			expected: true,
+		},
+		{
+			name:     "wildcard does not match lookalike domain",
+			hosts:    []string{"*.boot.dev"},
+			inputURL: "https://notboot.dev/",
+			expected: false,
+			rule:     "host not allowed: notboot.dev",
+		},
+		{
+			name:     "internationalized host matches its punycode form",
+			hosts:    []string{"bücher.example"},
+			inputURL: "https://xn--bcher-kva.example/katalog",
+			expected: true,
+		},
+		{
+			name:     "punycode host matches an internationalized URL",
+			hosts:    []string{"*.xn--bcher-kva.example"},
+			inputURL: "https://shop.bücher.example/",
+			expected: true,
+		},
+		{
+			name:     "internationalized host with a port",
+			hosts:    []string{"BÜCHER.example:8080"},
+			inputURL: "http://xn--bcher-kva.example:8080/",
+			expected: true,
+		},
+		{
+			name:     "path prefix",
+			prefixes: []string{"/docs/"},
+			inputURL: "https://www.boot.dev/blog/post",
+			expected: false,
+			rule:     "outside path prefixes: /docs/",
+		},
+		{
+			name:     "seed is exempt from path prefixes",
+			prefixes: []string{"/docs/"},
+			inputURL: "https://www.boot.dev",
+			expected: true,
+		},
+		{
+			name:     "exclude pattern",
+			exclude:  []string{"/tag/"},
+			inputURL: "https://www.boot.dev/tag/go",
+			expected: false,
+			rule:     "exclude: /tag/",
+		},
+		{
+			name:     "include pattern",
+			include:  []string{`/lessons/\d+`},
+			inputURL: "https://www.boot.dev/lessons/42",
+			expected: true,
+		},
+		{
+			name:     "include pattern not matched",
+			include:  []string{`/lessons/\d+`},
+			inputURL: "https://www.boot.dev/pricing",
+			expected: false,
+			rule:     "no include pattern matched",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			scope, err := newScopeRules(baseURL, tc.hosts, tc.prefixes, tc.include, tc.exclude)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			u, err := url.Parse(tc.inputURL)
+			if err != nil {
+				t.Fatalf("error parsing input URL: %v", err)
+			}
+
+			inScope, rule := scope.check(u)
+			if inScope != tc.expected {
+				t.Errorf("expected inScope %v, got %v", tc.expected, inScope)
+			}
+			if rule != tc.rule {
+				t.Errorf("expected rule %q, got %q", tc.rule, rule)
+			}
+		})
+	}
+}
+
+func TestNewScopeRulesInvalidPattern(t *testing.T) {
+	baseURL, _ := url.Parse("https://example.com")
+
+	if _, err := newScopeRules(baseURL, nil, nil, []string{"("}, nil); err == nil {
+		t.Error("expected error for invalid include pattern")
+	}
+	if _, err := newScopeRules(baseURL, nil, nil, nil, []string{"["}); err == nil {
+		t.Error("expected error for invalid exclude pattern")
+	}
+}
+
+func TestCrawlerRunRootSeedWithPathPrefix(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><h1>Page %s</h1><a href="/docs/intro">intro</a><a href="/blog/post">post</a></body></html>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	c, err := New([]Seed{{URL: server.URL + "/"}}, WithPathPrefixes("/docs/"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The root seed is crawled to reach /docs/, while other paths stay out of scope
+	site := result.Sites[0]
+	if len(site.Pages) != 2 {
+		t.Errorf("expected the seed and /docs/intro, got %v", site.Pages)
+	}
+	if _, exists := site.OutOfScope[server.URL+"/"]; exists {
+		t.Errorf("expected the seed to be in scope, got %v", site.OutOfScope)
+	}
+	if _, exists := site.OutOfScope[server.URL+"/blog/post"]; !exists {
+		t.Errorf("expected /blog/post to be out of scope, got %v", site.OutOfScope)
+	}
+}
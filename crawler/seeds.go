@@ -0,0 +1,70 @@
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Seed is a starting URL for one site, with an optional page budget of its own
+type Seed struct {
+	URL      string
+	MaxPages int // 0 means use the crawler's maxPages
+}
+
+// siteFilenameReplacer swaps characters that can't appear in file names
+var siteFilenameReplacer = strings.NewReplacer(":", "_", "/", "_", "?", "_", "&", "_", "=", "_")
+
+// siteKey identifies the site a seed starts: its host, plus its path and query when the seed isn't the host's root,
+// so seeds such as example.com/blog and example.com/docs are crawled as separate sites
+func siteKey(seedURL *url.URL) string {
+	key := strings.ToLower(seedURL.Host) + strings.TrimSuffix(seedURL.EscapedPath(), "/")
+	if seedURL.RawQuery != "" {
+		key += "?" + seedURL.RawQuery
+	}
+	return key
+}
+
+// SiteFilename turns a site key such as "example.com:8080/blog" into a string safe to use in file names
+func SiteFilename(site string) string {
+	return siteFilenameReplacer.Replace(site)
+}
+
+// ReadSeeds parses one seed per line as "URL [maxPages]", skipping blank lines and # comments
+func ReadSeeds(r io.Reader) ([]Seed, error) {
+	seeds := []Seed{}
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("line %d: expected \"URL [maxPages]\", got %q", lineNumber, line)
+		}
+
+		s := Seed{URL: fields[0]}
+		if len(fields) == 2 {
+			maxPages, err := strconv.Atoi(fields[1])
+			if err != nil || maxPages < 1 {
+				return nil, fmt.Errorf("line %d: invalid maxPages %q", lineNumber, fields[1])
+			}
+			s.MaxPages = maxPages
+		}
+		seeds = append(seeds, s)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return seeds, nil
+}
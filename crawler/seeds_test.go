@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadSeeds(t *testing.T) {
+	input := `# sites to audit
+https://blog.boot.dev
+
+https://www.boot.dev 50
+  https://example.com   5
+`
+
+	actual, err := ReadSeeds(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Seed{
+		{URL: "https://blog.boot.dev"},
+		{URL: "https://www.boot.dev", MaxPages: 50},
+		{URL: "https://example.com", MaxPages: 5},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestReadSeedsInvalid(t *testing.T) {
+	inputs := []string{
+		"https://example.com abc",
+		"https://example.com 0",
+		"https://example.com 5 extra",
+	}
+
+	for _, input := range inputs {
+		if _, err := ReadSeeds(strings.NewReader(input)); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestSiteKey(t *testing.T) {
+	tests := []struct {
+		seed     string
+		key      string
+		filename string
+	}{
+		{"https://example.com", "example.com", "example.com"},
+		{"https://Example.com/", "example.com", "example.com"},
+		{"http://localhost:8080/blog/", "localhost:8080/blog", "localhost_8080_blog"},
+		{"https://example.com/docs?lang=en", "example.com/docs?lang=en", "example.com_docs_lang_en"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.seed, func(t *testing.T) {
+			seedURL, err := url.Parse(tc.seed)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			key := siteKey(seedURL)
+			if key != tc.key {
+				t.Errorf("expected %q, got %q", tc.key, key)
+			}
+			if filename := SiteFilename(key); filename != tc.filename {
+				t.Errorf("expected %q, got %q", tc.filename, filename)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package crawler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxSitemaps caps how many sitemap files one site's sitemap index may pull in
+const maxSitemaps = 50
+
+// sitemapDocument covers both <urlset> sitemaps and <sitemapindex> files
+type sitemapDocument struct {
+	URLs []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+// parseSitemap reads a sitemap, returning URL -> lastmod for every entry with a valid lastmod,
+// plus the locations of any child sitemaps if it is a sitemap index
+func parseSitemap(r io.Reader) (lastMods map[string]time.Time, children []string, err error) {
+	var doc sitemapDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("error parsing sitemap: %w", err)
+	}
+
+	lastMods = make(map[string]time.Time)
+	for _, entry := range doc.URLs {
+		if lastMod, ok := parseLastMod(entry.LastMod); ok {
+			lastMods[strings.TrimSpace(entry.Loc)] = lastMod
+		}
+	}
+	for _, child := range doc.Sitemaps {
+		children = append(children, strings.TrimSpace(child.Loc))
+	}
+	return lastMods, children, nil
+}
+
+// parseLastMod accepts the W3C datetime formats allowed in sitemaps
+func parseLastMod(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// getSitemapLastMods fetches /sitemap.xml for a site, following one level of sitemap index
+func getSitemapLastMods(baseURL *url.URL, h *hooks) (map[string]time.Time, error) {
+	rootURL := baseURL.ResolveReference(&url.URL{Path: "/sitemap.xml"}).String()
+	lastMods, children, err := fetchSitemap(rootURL, h)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, child := range children {
+		if i >= maxSitemaps {
+			break
+		}
+		childLastMods, _, err := fetchSitemap(child, h)
+		if err != nil {
+			continue
+		}
+		for rawURL, lastMod := range childLastMods {
+			lastMods[rawURL] = lastMod
+		}
+	}
+	return lastMods, nil
+}
+
+// fetchSitemap downloads and parses one sitemap file
+func fetchSitemap(rawURL string, h *hooks) (map[string]time.Time, []string, error) {
+	client := &http.Client{Timeout: requestTimeout}
+
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "BootCrawler/1.0")
+	if err := h.request(req); err != nil {
+		return nil, nil, fmt.Errorf("request hook: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, nil, fmt.Errorf("HTTP error: status code %d", resp.StatusCode)
+	}
+	return parseSitemap(resp.Body)
+}
@@ -0,0 +1,59 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSitemap(t *testing.T) {
+	sitemap := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://example.com/a</loc><lastmod>2024-03-01</lastmod></url>
+  <url><loc> https://example.com/b/ </loc><lastmod>2024-03-02T10:00:00+00:00</lastmod></url>
+  <url><loc>https://example.com/c</loc></url>
+  <url><loc>https://example.com/d</loc><lastmod>yesterday</lastmod></url>
+</urlset>`
+
+	lastMods, children, err := parseSitemap(strings.NewReader(sitemap))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(children) != 0 {
+		t.Errorf("expected no child sitemaps, got %v", children)
+	}
+
+	expected := map[string]time.Time{
+		"https://example.com/a":  time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		"https://example.com/b/": time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC),
+	}
+	if len(lastMods) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, lastMods)
+	}
+	for rawURL, want := range expected {
+		if got := lastMods[rawURL]; !got.Equal(want) {
+			t.Errorf("expected %s lastmod %v, got %v", rawURL, want, got)
+		}
+	}
+}
+
+func TestParseSitemapIndex(t *testing.T) {
+	index := `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/sitemap-posts.xml</loc></sitemap>
+  <sitemap><loc>https://example.com/sitemap-pages.xml</loc></sitemap>
+</sitemapindex>`
+
+	_, children, err := parseSitemap(strings.NewReader(index))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(children) != 2 || children[0] != "https://example.com/sitemap-posts.xml" {
+		t.Errorf("expected 2 child sitemaps, got %v", children)
+	}
+}
+
+func TestParseSitemapInvalid(t *testing.T) {
+	if _, _, err := parseSitemap(strings.NewReader("not xml <")); err == nil {
+		t.Error("expected error for invalid XML")
+	}
+}
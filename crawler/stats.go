@@ -0,0 +1,236 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Reasons a URL was not crawled, or its links were not followed, as counted in Stats.Skipped
+const (
+	skipVisited        = "already_visited"
+	skipPageBudget     = "page_budget"
+	skipRunBudget      = "run_budget"
+	skipShutdown       = "shutdown"
+	skipInvalidURL     = "invalid_url"
+	skipOutOfScope     = "out_of_scope"
+	skipTrap           = "crawler_trap"
+	skipDuplicate      = "duplicate_content"
+	skipCanonicalAlias = "canonical_alias"
+	skipPageHook       = "dropped_by_page_hook"
+	skipLinkHook       = "rejected_by_link_hook"
+	skipNofollow       = "nofollow"
+	skipFetchFailed    = "fetch_failed"
+)
+
+// latencySampleSize is how many request latencies are kept for percentiles, so long crawls use bounded memory
+const latencySampleSize = 10000
+
+// Stats summarizes a whole run across every site
+type Stats struct {
+	Pages          int            `json:"pages"`    // Pages stored or streamed
+	Requests       int            `json:"requests"` // Page requests that got a response
+	Duration       float64        `json:"duration_seconds"`
+	PagesPerSecond float64        `json:"pages_per_second"`
+	Bytes          int64          `json:"bytes"` // Page content downloaded
+	StatusCodes    map[int]int    `json:"status_codes"`
+	Errors         map[string]int `json:"errors"` // Error category -> failed fetches
+	ContentTypes   map[string]int `json:"content_types"`
+	LatencyP50     float64        `json:"latency_p50_ms"`
+	LatencyP95     float64        `json:"latency_p95_ms"`
+	LatencyP99     float64        `json:"latency_p99_ms"`
+	Depths         map[int]int    `json:"depths"`  // Link depth from the seed -> pages
+	Skipped        map[string]int `json:"skipped"` // Skip reason -> URLs
+	StopReason     string         `json:"stop_reason,omitempty"`
+}
+
+// statsCollector gathers Stats while a run is in progress. Every method is safe on a nil collector.
+type statsCollector struct {
+	mu           *sync.Mutex
+	start        time.Time
+	requests     int
+	bytes        int64
+	statusCodes  map[int]int
+	errors       map[string]int
+	contentTypes map[string]int
+	latencies    []time.Duration // Uniform sample of at most latencySampleSize latencies
+	latencyCount int             // Latencies offered to the sample
+	depths       map[int]int
+	skipped      map[string]int
+}
+
+func newStatsCollector() *statsCollector {
+	return &statsCollector{
+		mu:           &sync.Mutex{},
+		start:        time.Now(),
+		statusCodes:  make(map[int]int),
+		errors:       make(map[string]int),
+		contentTypes: make(map[string]int),
+		depths:       make(map[int]int),
+		skipped:      make(map[string]int),
+	}
+}
+
+// recordFetch counts one page request and how it went
+func (s *statsCollector) recordFetch(result fetchResult, latency time.Duration, err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if result.statusCode != 0 {
+		s.requests++
+		s.statusCodes[result.statusCode]++
+		s.sampleLatency(latency)
+	}
+	if result.contentType != "" {
+		s.contentTypes[result.contentType]++
+	}
+	s.bytes += result.bytes
+	if err != nil {
+		s.errors[result.errorCategory]++
+	}
+}
+
+// sampleLatency adds a latency to the reservoir sample, replacing a random one once it is full; callers hold s.mu
+func (s *statsCollector) sampleLatency(latency time.Duration) {
+	s.latencyCount++
+	if len(s.latencies) < latencySampleSize {
+		s.latencies = append(s.latencies, latency)
+		return
+	}
+	if i := rand.IntN(s.latencyCount); i < latencySampleSize {
+		s.latencies[i] = latency
+	}
+}
+
+// recordPage counts a stored page at its link depth
+func (s *statsCollector) recordPage(depth int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.depths[depth]++
+}
+
+// recordSkip counts a URL that was not crawled, or whose links were not followed
+func (s *statsCollector) recordSkip(reason string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.skipped[reason]++
+}
+
+// snapshot computes the run's Stats, given the pages every site stored
+func (s *statsCollector) snapshot(pages int, stopReason string) Stats {
+	stats := Stats{Pages: pages, StopReason: stopReason}
+	if s == nil {
+		return stats
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := time.Since(s.start).Seconds()
+	stats.Requests = s.requests
+	stats.Duration = elapsed
+	if elapsed > 0 {
+		stats.PagesPerSecond = float64(pages) / elapsed
+	}
+	stats.Bytes = s.bytes
+	stats.StatusCodes = copyCounts(s.statusCodes)
+	stats.Errors = copyCounts(s.errors)
+	stats.ContentTypes = copyCounts(s.contentTypes)
+	stats.Depths = copyCounts(s.depths)
+	stats.Skipped = copyCounts(s.skipped)
+
+	latencies := append([]time.Duration(nil), s.latencies...)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	stats.LatencyP50 = percentileMS(latencies, 50)
+	stats.LatencyP95 = percentileMS(latencies, 95)
+	stats.LatencyP99 = percentileMS(latencies, 99)
+	return stats
+}
+
+// copyCounts copies a counter map so callers can't race with the collector
+func copyCounts[K comparable](counts map[K]int) map[K]int {
+	copied := make(map[K]int, len(counts))
+	for key, count := range counts {
+		copied[key] = count
+	}
+	return copied
+}
+
+// percentileMS returns the nearest-rank percentile of sorted latencies in milliseconds
+func percentileMS(sorted []time.Duration, percentile float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	rank = min(max(rank, 1), len(sorted))
+	return float64(sorted[rank-1].Microseconds()) / 1000
+}
+
+// WriteSummary prints the statistics as a human-readable table
+func (s Stats) WriteSummary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Pages\t%d\n", s.Pages)
+	fmt.Fprintf(tw, "Requests\t%d\n", s.Requests)
+	fmt.Fprintf(tw, "Duration\t%.1fs\n", s.Duration)
+	fmt.Fprintf(tw, "Pages/sec\t%.2f\n", s.PagesPerSecond)
+	fmt.Fprintf(tw, "Bytes\t%d\n", s.Bytes)
+	fmt.Fprintf(tw, "Latency p50/p95/p99\t%.0fms / %.0fms / %.0fms\n", s.LatencyP50, s.LatencyP95, s.LatencyP99)
+	if s.StopReason != "" {
+		fmt.Fprintf(tw, "Stopped by\t%s\n", s.StopReason)
+	}
+
+	writeCounts(tw, "Status codes", s.StatusCodes)
+	writeCounts(tw, "Errors", s.Errors)
+	writeCounts(tw, "Content types", s.ContentTypes)
+	writeCounts(tw, "Depths", s.Depths)
+	writeCounts(tw, "Skipped", s.Skipped)
+	return tw.Flush()
+}
+
+// writeCounts prints one table section, keys in sorted order
+func writeCounts[K int | string](w io.Writer, title string, counts map[K]int) {
+	if len(counts) == 0 {
+		return
+	}
+	keys := make([]K, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	fmt.Fprintf(w, "%s\n", title)
+	for _, key := range keys {
+		fmt.Fprintf(w, "  %v\t%d\n", key, counts[key])
+	}
+}
+
+// statsReportFilename derives the JSON statistics filename, e.g. report.csv -> report_stats.json
+func statsReportFilename(filename string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + "_stats.json"
+}
+
+// writeStatsReport exports the statistics as JSON
+func writeStatsReport(stats Stats, filename string) error {
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0o644)
+}
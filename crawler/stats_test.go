@@ -0,0 +1,131 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPercentileMS(t *testing.T) {
+	latencies := []time.Duration{}
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	tests := []struct {
+		percentile float64
+		expected   float64
+	}{
+		{50, 50},
+		{95, 95},
+		{99, 99},
+		{100, 100},
+	}
+	for _, tc := range tests {
+		if got := percentileMS(latencies, tc.percentile); got != tc.expected {
+			t.Errorf("expected p%v of %v, got %v", tc.percentile, tc.expected, got)
+		}
+	}
+
+	if got := percentileMS(nil, 50); got != 0 {
+		t.Errorf("expected 0 without latencies, got %v", got)
+	}
+}
+
+func TestStatsLatencySample(t *testing.T) {
+	stats := newStatsCollector()
+	requests := 3 * latencySampleSize
+	for i := range requests {
+		// Every tenth request is slow, so the sample should keep about that share
+		latency := 10 * time.Millisecond
+		if i%10 == 0 {
+			latency = time.Second
+		}
+		stats.recordFetch(fetchResult{statusCode: http.StatusOK}, latency, nil)
+	}
+
+	if len(stats.latencies) != latencySampleSize {
+		t.Errorf("expected the sample to stay at %d latencies, got %d", latencySampleSize, len(stats.latencies))
+	}
+	snapshot := stats.snapshot(0, "")
+	if snapshot.Requests != requests {
+		t.Errorf("expected %d requests, got %d", requests, snapshot.Requests)
+	}
+	if snapshot.LatencyP50 != 10 || snapshot.LatencyP99 != 1000 {
+		t.Errorf("expected p50 10ms and p99 1000ms, got %vms and %vms", snapshot.LatencyP50, snapshot.LatencyP99)
+	}
+}
+
+func TestStatsReportFilename(t *testing.T) {
+	if got := statsReportFilename("report.csv"); got != "report_stats.json" {
+		t.Errorf("expected report_stats.json, got %s", got)
+	}
+}
+
+func TestStatsCollected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/data.json":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{}`)
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, `<html><body><a href="/one">1</a><a href="/missing">m</a><a href="/data.json">d</a><a href="https://other.example/">o</a></body></html>`)
+		}
+	}))
+	defer server.Close()
+
+	c, err := New([]Seed{{URL: server.URL}}, WithMaxConcurrency(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stats := result.Stats
+	if stats.Pages != 2 || stats.Requests != 4 {
+		t.Errorf("expected 2 pages from 4 requests, got %d from %d", stats.Pages, stats.Requests)
+	}
+	if stats.StatusCodes[200] != 3 || stats.StatusCodes[404] != 1 {
+		t.Errorf("expected three 200s and one 404, got %v", stats.StatusCodes)
+	}
+	if stats.Errors["http_4xx"] != 1 || stats.Errors["content_type"] != 1 {
+		t.Errorf("expected one http_4xx and one content_type error, got %v", stats.Errors)
+	}
+	if stats.ContentTypes["text/html"] != 2 || stats.ContentTypes["text/plain"] != 1 || stats.ContentTypes["application/json"] != 1 {
+		t.Errorf("expected content types without parameters, got %v", stats.ContentTypes)
+	}
+	if stats.Depths[0] != 1 || stats.Depths[1] != 1 {
+		t.Errorf("expected one page at depth 0 and one at depth 1, got %v", stats.Depths)
+	}
+
+	// /one serves the seed's content, so its links aren't followed again
+	expectedSkips := map[string]int{skipOutOfScope: 1, skipFetchFailed: 2, skipDuplicate: 1}
+	for reason, count := range expectedSkips {
+		if stats.Skipped[reason] != count {
+			t.Errorf("expected %d %s skips, got %v", count, reason, stats.Skipped)
+		}
+	}
+	if stats.Bytes == 0 || stats.LatencyP99 < stats.LatencyP50 {
+		t.Errorf("expected bytes and ordered latencies, got %+v", stats)
+	}
+
+	var summary bytes.Buffer
+	if err := stats.WriteSummary(&summary); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"Pages", "Latency p50/p95/p99", "Status codes", "  404", "Skipped", "  out_of_scope"} {
+		if !strings.Contains(summary.String(), want) {
+			t.Errorf("expected summary to contain %q, got:\n%s", want, summary.String())
+		}
+	}
+}
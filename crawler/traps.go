@@ -0,0 +1,160 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Default crawler trap detection limits
+const (
+	DefaultMaxPathDepth        = 16
+	DefaultMaxURLLength        = 1024
+	DefaultMaxRepeatedSegments = 3
+	DefaultMaxQueryVariants    = 50
+)
+
+// trapDetector spots crawler traps such as infinite calendars and recursive links
+type trapDetector struct {
+	mu                  *sync.Mutex
+	maxPathDepth        int                            // Maximum number of path segments
+	maxURLLength        int                            // Maximum length of the whole URL
+	maxRepeatedSegments int                            // Maximum times one segment may appear in a path
+	maxQueryVariants    int                            // Maximum distinct query strings for one path
+	queryVariants       map[string]map[string]struct{} // host+path -> distinct query strings seen
+	trapped             map[string]*TrapRecord         // URL pattern -> why it was cut off
+	maxRecords          int                            // Most entries in queryVariants and trapped, 0 or less for no limit
+	recordsDropped      int                            // Entries left out of those maps once they were full
+}
+
+// TrapRecord describes one URL pattern cut off as a crawler trap
+type TrapRecord struct {
+	Reason string // Heuristic that flagged the pattern
+	Hits   int    // Number of discovered URLs matching the pattern
+}
+
+// newTrapDetector creates a detector with the given limits, where 0 disables a check
+func newTrapDetector(maxPathDepth, maxURLLength, maxRepeatedSegments, maxQueryVariants int) *trapDetector {
+	return &trapDetector{
+		mu:                  &sync.Mutex{},
+		maxPathDepth:        maxPathDepth,
+		maxURLLength:        maxURLLength,
+		maxRepeatedSegments: maxRepeatedSegments,
+		maxQueryVariants:    maxQueryVariants,
+		queryVariants:       make(map[string]map[string]struct{}),
+		trapped:             make(map[string]*TrapRecord),
+	}
+}
+
+// check reports whether a URL looks like a trap, recording its pattern if so
+func (d *trapDetector) check(u *url.URL) (trapped bool, reason string) {
+	if d == nil {
+		return false, ""
+	}
+
+	pattern, reason := d.detect(u)
+	if pattern == "" {
+		return false, ""
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	record, exists := d.trapped[pattern]
+	if !exists {
+		// The URL is still cut off when there is no room left to record its pattern
+		if !d.roomForRecord(len(d.trapped)) {
+			return true, reason
+		}
+		record = &TrapRecord{Reason: reason}
+		d.trapped[pattern] = record
+	}
+	record.Hits++
+
+	return true, reason
+}
+
+// roomForRecord reports whether a map holding size entries may take another one under maxRecords,
+// counting the entry as dropped when it can't; callers hold d.mu
+func (d *trapDetector) roomForRecord(size int) bool {
+	if d.maxRecords <= 0 || size < d.maxRecords {
+		return true
+	}
+	d.recordsDropped++
+	return false
+}
+
+// droppedRecords returns how many entries were left out once the detector's maps were full
+func (d *trapDetector) droppedRecords() int {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.recordsDropped
+}
+
+// detect runs each heuristic and returns the trapped pattern, or "" if the URL looks fine
+func (d *trapDetector) detect(u *url.URL) (pattern, reason string) {
+	host := strings.ToLower(u.Host)
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+
+	if d.maxURLLength > 0 && len(u.String()) > d.maxURLLength {
+		return host + u.Path + "?*", fmt.Sprintf("URL longer than %d characters", d.maxURLLength)
+	}
+
+	if d.maxPathDepth > 0 && len(segments) > d.maxPathDepth {
+		prefix := strings.Join(segments[:d.maxPathDepth], "/")
+		return host + "/" + prefix + "/*", fmt.Sprintf("path deeper than %d segments", d.maxPathDepth)
+	}
+
+	if d.maxRepeatedSegments > 0 {
+		counts := make(map[string]int)
+		for i, segment := range segments {
+			counts[segment]++
+			if counts[segment] > d.maxRepeatedSegments {
+				prefix := strings.Join(segments[:i], "/")
+				return host + "/" + prefix + "/*", fmt.Sprintf("path segment %q repeated more than %d times", segment, d.maxRepeatedSegments)
+			}
+		}
+	}
+
+	if d.maxQueryVariants > 0 && u.RawQuery != "" {
+		key := host + u.Path
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		variants, exists := d.queryVariants[key]
+		if !exists {
+			// Paths beyond the cap aren't tracked, so their query variants go unchecked
+			if !d.roomForRecord(len(d.queryVariants)) {
+				return "", ""
+			}
+			variants = make(map[string]struct{})
+			d.queryVariants[key] = variants
+		}
+		if _, seen := variants[u.RawQuery]; !seen {
+			if len(variants) >= d.maxQueryVariants {
+				return key + "?*", fmt.Sprintf("more than %d query variants", d.maxQueryVariants)
+			}
+			variants[u.RawQuery] = struct{}{}
+		}
+	}
+
+	return "", ""
+}
+
+// trappedPatterns returns a snapshot of every trapped URL pattern
+func (d *trapDetector) trappedPatterns() map[string]TrapRecord {
+	patterns := make(map[string]TrapRecord)
+	if d == nil {
+		return patterns
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for pattern, record := range d.trapped {
+		patterns[pattern] = *record
+	}
+	return patterns
+}
@@ -0,0 +1,121 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+func TestTrapDetectorCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		inputURL string
+		expected bool
+	}{
+		{
+			name:     "ordinary page",
+			inputURL: "https://example.com/blog/post",
+			expected: false,
+		},
+		{
+			name:     "recursive relative links",
+			inputURL: "https://example.com/a/b/a/b/a/b/a/b",
+			expected: true,
+		},
+		{
+			name:     "excessive depth",
+			inputURL: "https://example.com/1/2/3/4/5/6",
+			expected: true,
+		},
+		{
+			name:     "excessive length",
+			inputURL: "https://example.com/" + fmt.Sprintf("%060d", 0),
+			expected: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			detector := newTrapDetector(5, 60, 3, 0)
+
+			u, err := url.Parse(tc.inputURL)
+			if err != nil {
+				t.Fatalf("error parsing input URL: %v", err)
+			}
+
+			trapped, reason := detector.check(u)
+			if trapped != tc.expected {
+				t.Errorf("expected trapped %v, got %v (%s)", tc.expected, trapped, reason)
+			}
+		})
+	}
+}
+
+func TestTrapDetectorQueryVariants(t *testing.T) {
+	detector := newTrapDetector(0, 0, 0, 3)
+
+	// The first three distinct queries for a path are allowed, repeats are free
+	for _, query := range []string{"month=1", "month=2", "month=3", "month=1"} {
+		u, _ := url.Parse("https://example.com/calendar?" + query)
+		if trapped, reason := detector.check(u); trapped {
+			t.Fatalf("expected %s not to be trapped, got %s", query, reason)
+		}
+	}
+
+	// Further variants are cut off and recorded under one pattern
+	for _, query := range []string{"month=4", "month=5"} {
+		u, _ := url.Parse("https://example.com/calendar?" + query)
+		if trapped, _ := detector.check(u); !trapped {
+			t.Errorf("expected %s to be trapped", query)
+		}
+	}
+
+	patterns := detector.trappedPatterns()
+	record, exists := patterns["example.com/calendar?*"]
+	if !exists {
+		t.Fatalf("expected calendar pattern to be recorded, got %v", patterns)
+	}
+	if record.Hits != 2 {
+		t.Errorf("expected 2 hits, got %d", record.Hits)
+	}
+}
+
+func TestTrapDetectorMaxRecords(t *testing.T) {
+	detector := newTrapDetector(2, 0, 0, 1)
+	detector.maxRecords = 1
+
+	// Only the first deep pattern is recorded, but every deep URL is still cut off
+	for _, rawURL := range []string{"https://example.com/a/b/c", "https://example.com/x/y/z", "https://example.com/x/y/w"} {
+		u, _ := url.Parse(rawURL)
+		if trapped, _ := detector.check(u); !trapped {
+			t.Errorf("expected %s to be trapped", rawURL)
+		}
+	}
+	if patterns := detector.trappedPatterns(); len(patterns) != 1 {
+		t.Errorf("expected 1 recorded pattern, got %v", patterns)
+	}
+
+	// Only the first path's query variants are tracked
+	for _, rawURL := range []string{"https://example.com/list?page=1", "https://example.com/search?q=1", "https://example.com/search?q=2"} {
+		u, _ := url.Parse(rawURL)
+		if trapped, reason := detector.check(u); trapped {
+			t.Errorf("expected %s not to be trapped, got %s", rawURL, reason)
+		}
+	}
+	if len(detector.queryVariants) != 1 {
+		t.Errorf("expected 1 tracked path, got %d", len(detector.queryVariants))
+	}
+
+	if dropped := detector.droppedRecords(); dropped != 4 {
+		t.Errorf("expected 4 dropped records, got %d", dropped)
+	}
+}
+
+func TestTrapDetectorNil(t *testing.T) {
+	var detector *trapDetector
+
+	u, _ := url.Parse("https://example.com/a/a/a/a/a/a/a/a")
+	if trapped, _ := detector.check(u); trapped {
+		t.Error("expected a nil detector to never report traps")
+	}
+}
@@ -0,0 +1,308 @@
+package crawler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"sync"
+)
+
+// VisitedSet records which normalized URLs have been crawled. Implementations are safe for concurrent use.
+type VisitedSet interface {
+	// Add marks key as visited, reporting whether it was not visited before
+	Add(key string) (isNew bool, err error)
+	// Len returns the number of keys added
+	Len() int
+	// Close releases any resources held by the set
+	Close() error
+}
+
+// memoryVisitedSet is the default VisitedSet, an exact in-memory set
+type memoryVisitedSet struct {
+	mu   *sync.Mutex
+	keys map[string]struct{}
+}
+
+// NewMemoryVisitedSet creates an exact in-memory visited set
+func NewMemoryVisitedSet() VisitedSet {
+	return &memoryVisitedSet{mu: &sync.Mutex{}, keys: make(map[string]struct{})}
+}
+
+func (s *memoryVisitedSet) Add(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.keys[key]; exists {
+		return false, nil
+	}
+	s.keys[key] = struct{}{}
+	return true, nil
+}
+
+func (s *memoryVisitedSet) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.keys)
+}
+
+func (s *memoryVisitedSet) Close() error {
+	return nil
+}
+
+// bloomVisitedSet is a Bloom filter: constant memory, but a false positive skips a page that wasn't visited
+type bloomVisitedSet struct {
+	mu     *sync.Mutex
+	bits   []uint64
+	m      uint64 // Number of bits
+	k      uint64 // Number of hash functions
+	length int
+}
+
+// NewBloomVisitedSet creates a Bloom filter sized for expectedItems keys at the given false-positive rate
+func NewBloomVisitedSet(expectedItems int, falsePositiveRate float64) (VisitedSet, error) {
+	if expectedItems < 1 {
+		return nil, errors.New("bloom filter needs at least 1 expected item")
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		return nil, errors.New("bloom filter false-positive rate must be between 0 and 1")
+	}
+
+	// Standard sizing: m = -n ln p / (ln 2)^2 bits and k = m/n ln 2 hash functions
+	n := float64(expectedItems)
+	m := uint64(math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Max(1, math.Round(float64(m)/n*math.Ln2)))
+
+	return &bloomVisitedSet{
+		mu:   &sync.Mutex{},
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}, nil
+}
+
+func (s *bloomVisitedSet) Add(key string) (bool, error) {
+	// Double hashing derives all k bit positions from two well-mixed base hashes
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	a, b := mix64(h.Sum64()), mix64(h.Sum64()^0x9e3779b97f4a7c15)|1
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	isNew := false
+	for i := uint64(0); i < s.k; i++ {
+		bit := (a + i*b) % s.m
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if s.bits[word]&mask == 0 {
+			isNew = true
+			s.bits[word] |= mask
+		}
+	}
+	if isNew {
+		s.length++
+	}
+	return isNew, nil
+}
+
+// mix64 is the splitmix64 finalizer, spreading FNV's weak low bits across the whole word
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+func (s *bloomVisitedSet) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.length
+}
+
+func (s *bloomVisitedSet) Close() error {
+	return nil
+}
+
+// diskSlotSize is the size of one key digest in the on-disk hash table
+const diskSlotSize = 16
+
+// diskInitialSlots is the starting capacity of the on-disk hash table (a power of two)
+const diskInitialSlots = 1 << 16
+
+// diskVisitedSet is an exact visited set kept in an on-disk open-addressing hash table of key digests
+type diskVisitedSet struct {
+	mu            *sync.Mutex
+	path          string
+	file          *os.File
+	slots         uint64 // Table capacity, always a power of two
+	length        int
+	removeOnClose bool // Remove the file on Close
+}
+
+// NewDiskVisitedSet creates an on-disk visited set at path, replacing any existing file. The file is
+// left in place on Close. Only 16-byte key digests are stored, and the table doubles when it is half full.
+func NewDiskVisitedSet(path string) (VisitedSet, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return newDiskVisitedSet(file, false)
+}
+
+// NewTempDiskVisitedSet creates an on-disk visited set in a new file in dir, named from pattern as
+// os.CreateTemp does, so crawls running side by side never share a table. Close removes the file
+// unless keep is set.
+func NewTempDiskVisitedSet(dir, pattern string, keep bool) (VisitedSet, error) {
+	file, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return newDiskVisitedSet(file, !keep)
+}
+
+// newDiskVisitedSet sizes an empty table file for a new visited set
+func newDiskVisitedSet(file *os.File, removeOnClose bool) (VisitedSet, error) {
+	if err := file.Truncate(int64(diskInitialSlots * diskSlotSize)); err != nil {
+		file.Close()
+		if removeOnClose {
+			os.Remove(file.Name())
+		}
+		return nil, err
+	}
+	return &diskVisitedSet{mu: &sync.Mutex{}, path: file.Name(), file: file, slots: diskInitialSlots, removeOnClose: removeOnClose}, nil
+}
+
+// createDiskTable creates an empty table file with room for slots digests
+func createDiskTable(path string, slots uint64) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Truncate(int64(slots * diskSlotSize)); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// keyDigest hashes a key to a non-zero digest, since all-zero marks an empty slot
+func keyDigest(key string) [diskSlotSize]byte {
+	sum := sha256.Sum256([]byte(key))
+	var digest [diskSlotSize]byte
+	copy(digest[:], sum[:diskSlotSize])
+	digest[0] |= 1
+	return digest
+}
+
+func (s *diskVisitedSet) Add(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Keep the load factor at or below one half so probes stay short
+	if uint64(s.length+1)*2 > s.slots {
+		if err := s.grow(); err != nil {
+			return false, fmt.Errorf("growing visited set: %w", err)
+		}
+	}
+
+	inserted, err := insertDigest(s.file, s.slots, keyDigest(key))
+	if err != nil {
+		return false, err
+	}
+	if inserted {
+		s.length++
+	}
+	return inserted, nil
+}
+
+// insertDigest linear-probes the table for digest, writing it to the first empty slot if it is missing
+func insertDigest(file *os.File, slots uint64, digest [diskSlotSize]byte) (inserted bool, err error) {
+	var slot [diskSlotSize]byte
+	var empty [diskSlotSize]byte
+
+	index := binary.BigEndian.Uint64(digest[diskSlotSize-8:]) & (slots - 1)
+	for {
+		offset := int64(index * diskSlotSize)
+		if _, err := file.ReadAt(slot[:], offset); err != nil {
+			return false, err
+		}
+		if bytes.Equal(slot[:], digest[:]) {
+			return false, nil
+		}
+		if bytes.Equal(slot[:], empty[:]) {
+			if _, err := file.WriteAt(digest[:], offset); err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+		index = (index + 1) & (slots - 1)
+	}
+}
+
+// grow rehashes every digest into a table twice the size
+func (s *diskVisitedSet) grow() error {
+	newSlots := s.slots * 2
+	tmpPath := s.path + ".grow"
+	newFile, err := createDiskTable(tmpPath, newSlots)
+	if err != nil {
+		return err
+	}
+
+	// Copy digests over in chunks to keep the number of reads down
+	chunk := make([]byte, 4096*diskSlotSize)
+	var empty [diskSlotSize]byte
+	for offset := int64(0); offset < int64(s.slots*diskSlotSize); offset += int64(len(chunk)) {
+		n, err := s.file.ReadAt(chunk, offset)
+		if err != nil && n == 0 {
+			newFile.Close()
+			return err
+		}
+		for i := 0; i+diskSlotSize <= n; i += diskSlotSize {
+			var digest [diskSlotSize]byte
+			copy(digest[:], chunk[i:i+diskSlotSize])
+			if digest == empty {
+				continue
+			}
+			if _, err := insertDigest(newFile, newSlots, digest); err != nil {
+				newFile.Close()
+				return err
+			}
+		}
+	}
+
+	if err := s.file.Close(); err != nil {
+		newFile.Close()
+		return err
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		newFile.Close()
+		return err
+	}
+	s.file = newFile
+	s.slots = newSlots
+	return nil
+}
+
+func (s *diskVisitedSet) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.length
+}
+
+func (s *diskVisitedSet) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.file.Close()
+	if s.removeOnClose {
+		if removeErr := os.Remove(s.path); err == nil {
+			err = removeErr
+		}
+	}
+	return err
+}
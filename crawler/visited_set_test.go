@@ -0,0 +1,173 @@
+package crawler
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestVisitedSets(t *testing.T) {
+	bloom, err := NewBloomVisitedSet(1000, 0.001)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	disk, err := NewDiskVisitedSet(filepath.Join(t.TempDir(), "visited.db"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sets := map[string]VisitedSet{
+		"memory": NewMemoryVisitedSet(),
+		"bloom":  bloom,
+		"disk":   disk,
+	}
+
+	for name, set := range sets {
+		t.Run(name, func(t *testing.T) {
+			defer set.Close()
+
+			isNew, err := set.Add("example.com/a")
+			if err != nil || !isNew {
+				t.Fatalf("expected first add to be new, got %v, %v", isNew, err)
+			}
+			isNew, err = set.Add("example.com/a")
+			if err != nil || isNew {
+				t.Fatalf("expected second add not to be new, got %v, %v", isNew, err)
+			}
+			isNew, err = set.Add("example.com/b")
+			if err != nil || !isNew {
+				t.Fatalf("expected a different key to be new, got %v, %v", isNew, err)
+			}
+			if set.Len() != 2 {
+				t.Errorf("expected Len 2, got %d", set.Len())
+			}
+		})
+	}
+}
+
+func TestTempDiskVisitedSet(t *testing.T) {
+	dir := t.TempDir()
+
+	// Sets for the same site get their own files
+	first, err := NewTempDiskVisitedSet(dir, "visited_example.com_*.db", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := NewTempDiskVisitedSet(dir, "visited_example.com_*.db", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if isNew, err := first.Add("example.com/a"); err != nil || !isNew {
+		t.Fatalf("expected first add to be new, got %v, %v", isNew, err)
+	}
+	if isNew, err := second.Add("example.com/a"); err != nil || !isNew {
+		t.Errorf("expected the second set not to share the first one's table, got %v, %v", isNew, err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "visited_example.com_*.db"))
+	if len(files) != 2 {
+		t.Fatalf("expected 2 visited set files, got %v", files)
+	}
+
+	// Closing removes the file unless it was kept
+	if err := first.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := second.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	files, _ = filepath.Glob(filepath.Join(dir, "visited_example.com_*.db"))
+	if len(files) != 1 {
+		t.Errorf("expected only the kept file to remain, got %v", files)
+	}
+}
+
+func TestDiskVisitedSetGrows(t *testing.T) {
+	set, err := NewDiskVisitedSet(filepath.Join(t.TempDir(), "visited.db"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer set.Close()
+
+	// Enough keys to force at least one rehash of the initial table
+	count := diskInitialSlots/2 + 100
+	for i := 0; i < count; i++ {
+		if isNew, err := set.Add(fmt.Sprintf("example.com/page%d", i)); err != nil || !isNew {
+			t.Fatalf("key %d: expected new, got %v, %v", i, isNew, err)
+		}
+	}
+
+	// Every key survives the rehash
+	for i := 0; i < count; i += 997 {
+		if isNew, err := set.Add(fmt.Sprintf("example.com/page%d", i)); err != nil || isNew {
+			t.Fatalf("key %d: expected already visited, got %v, %v", i, isNew, err)
+		}
+	}
+	if set.Len() != count {
+		t.Errorf("expected Len %d, got %d", count, set.Len())
+	}
+}
+
+func TestBloomVisitedSetFalsePositiveRate(t *testing.T) {
+	set, err := NewBloomVisitedSet(10000, 0.01)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < 10000; i++ {
+		set.Add(fmt.Sprintf("example.com/page%d", i))
+	}
+
+	// Unseen keys should rarely be reported as visited. Probing also adds keys,
+	// so only probe a few to keep the filter near its designed load.
+	falsePositives := 0
+	for i := 0; i < 1000; i++ {
+		if isNew, _ := set.Add(fmt.Sprintf("other.com/page%d", i)); !isNew {
+			falsePositives++
+		}
+	}
+	if falsePositives > 30 {
+		t.Errorf("expected roughly 1%% false positives, got %d in 1000", falsePositives)
+	}
+}
+
+func TestNewBloomVisitedSetInvalid(t *testing.T) {
+	if _, err := NewBloomVisitedSet(0, 0.01); err == nil {
+		t.Error("expected error for zero capacity")
+	}
+	if _, err := NewBloomVisitedSet(100, 1); err == nil {
+		t.Error("expected error for false-positive rate of 1")
+	}
+}
+
+func TestVisitedSetConcurrentAdd(t *testing.T) {
+	disk, err := NewDiskVisitedSet(filepath.Join(t.TempDir(), "visited.db"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer disk.Close()
+
+	var wg sync.WaitGroup
+	newCount := make(chan bool, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			isNew, _ := disk.Add("example.com")
+			newCount <- isNew
+		}()
+	}
+	wg.Wait()
+	close(newCount)
+
+	firsts := 0
+	for isNew := range newCount {
+		if isNew {
+			firsts++
+		}
+	}
+	if firsts != 1 {
+		t.Errorf("expected exactly one first visit, got %d", firsts)
+	}
+}
@@ -0,0 +1,180 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// workerPollInterval is how long an idle worker waits before asking for more work
+const workerPollInterval = 500 * time.Millisecond
+
+// workerMaxRetries is how many consecutive failed calls a worker tolerates before giving up on the coordinator
+const workerMaxRetries = 5
+
+// Worker fetches pages leased from a Coordinator and submits what it extracts.
+// It uses its crawler's concurrency, request and response hooks and log output;
+// scope, traps, deduplication and page hooks run on the coordinator.
+type Worker struct {
+	crawler        *Crawler
+	coordinatorURL string
+	id             string
+	client         *http.Client
+}
+
+// NewWorker creates a worker for the coordinator at coordinatorURL, e.g. "http://10.0.0.5:8080".
+// An empty id defaults to the hostname and process ID.
+func (c *Crawler) NewWorker(coordinatorURL, id string) *Worker {
+	if id == "" {
+		hostname, _ := os.Hostname()
+		id = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	return &Worker{
+		crawler:        c,
+		coordinatorURL: strings.TrimSuffix(coordinatorURL, "/"),
+		id:             id,
+		client:         &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Run leases and crawls batches until the coordinator reports the crawl is done.
+// Cancelling ctx stops leasing; the batch in hand is still crawled and submitted.
+func (w *Worker) Run(ctx context.Context) error {
+	failures := 0
+	for ctx.Err() == nil {
+		lease, err := w.lease()
+		if err != nil {
+			failures++
+			if failures >= workerMaxRetries {
+				return fmt.Errorf("error leasing work from coordinator: %w", err)
+			}
+			w.logf("error leasing work, retrying: %v\n", err)
+			sleepContext(ctx, workerPollInterval*time.Duration(failures))
+			continue
+		}
+		failures = 0
+
+		if lease.Done {
+			return nil
+		}
+		if len(lease.URLs) == 0 {
+			sleepContext(ctx, workerPollInterval)
+			continue
+		}
+
+		// A rejected submission means our lease expired and the URLs went to another worker
+		if err := w.submit(w.crawlBatch(lease)); err != nil {
+			w.logf("error submitting lease %s: %v\n", lease.LeaseID, err)
+		}
+	}
+	return nil
+}
+
+// crawlBatch fetches every leased URL, at most maxConcurrency at a time
+func (w *Worker) crawlBatch(lease leaseResponse) submitRequest {
+	result := submitRequest{LeaseID: lease.LeaseID, WorkerID: w.id}
+	mu := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	concurrencyControl := make(chan struct{}, w.crawler.maxConcurrency)
+
+	for _, rawURL := range lease.URLs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			concurrencyControl <- struct{}{}
+			defer func() { <-concurrencyControl }()
+
+			page, err := w.crawlURL(rawURL)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				w.logf("error crawling %s: %v\n", rawURL, err)
+				result.Failed = append(result.Failed, failedURL{URL: rawURL, Fetch: page.Fetch})
+				return
+			}
+			result.Pages = append(result.Pages, page)
+		}()
+	}
+	wg.Wait()
+
+	return result
+}
+
+// crawlURL fetches one page and extracts its data and links. The page's Fetch is filled in even when it fails.
+func (w *Worker) crawlURL(rawURL string) (submittedPage, error) {
+	if _, err := url.Parse(rawURL); err != nil {
+		return submittedPage{Fetch: fetchReport{ErrorCategory: "invalid_request"}}, err
+	}
+
+	w.logf("crawling: %s\n", rawURL)
+	start := time.Now()
+	fetched, err := fetchPage(rawURL, &w.crawler.hooks, nil)
+	page := submittedPage{URL: rawURL, Fetch: newFetchReport(fetched, time.Since(start))}
+	if err != nil {
+		return page, err
+	}
+
+	page.Page = extractPageData(fetched.html, rawURL)
+	page.Page.ETag, page.Page.LastModified, page.Page.CrawledAt = fetched.etag, fetched.lastModified, time.Now()
+	page.Links = page.Page.OutgoingLinks
+	return page, nil
+}
+
+// lease asks the coordinator for the next batch
+func (w *Worker) lease() (leaseResponse, error) {
+	var resp leaseResponse
+	err := w.post("/lease", leaseRequest{WorkerID: w.id, Max: w.crawler.maxConcurrency * 2}, &resp)
+	return resp, err
+}
+
+// submit sends a batch's results back to the coordinator
+func (w *Worker) submit(result submitRequest) error {
+	return w.post("/submit", result, nil)
+}
+
+// post sends body as JSON and decodes the response into out unless it is nil
+func (w *Worker) post(path string, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Post(w.coordinatorURL+path, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("coordinator returned status code %d", resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// sleepContext waits for d or until ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// logf writes a progress message if the crawler has a log output
+func (w *Worker) logf(format string, args ...any) {
+	if w.crawler.log != nil {
+		fmt.Fprintf(w.crawler.log, format, args...)
+	}
+}
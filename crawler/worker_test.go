@@ -0,0 +1,63 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerStopsWhenCancelled(t *testing.T) {
+	// A coordinator that never has work keeps the worker polling
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls.Add(1)
+		writeJSON(w, leaseResponse{URLs: []string{}})
+	}))
+	defer server.Close()
+
+	c, err := New([]Seed{{URL: "https://example.com"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := c.NewWorker(server.URL, "").Run(ctx); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if polls.Load() == 0 {
+		t.Error("expected the worker to poll the coordinator")
+	}
+}
+
+func TestWorkerCrawlBatch(t *testing.T) {
+	site := newLinkedSite(t)
+
+	c, err := New([]Seed{{URL: site.URL}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w := c.NewWorker("http://unused", "worker")
+
+	result := w.crawlBatch(leaseResponse{LeaseID: "1", URLs: []string{site.URL + "/a", "http://127.0.0.1:1/unreachable"}})
+	if result.LeaseID != "1" || result.WorkerID != "worker" {
+		t.Errorf("expected lease 1 from worker, got %s from %s", result.LeaseID, result.WorkerID)
+	}
+	if len(result.Pages) != 1 || len(result.Pages[0].Links) != 4 {
+		t.Fatalf("expected 1 page with 4 links, got %v", result.Pages)
+	}
+	if len(result.Failed) != 1 {
+		t.Fatalf("expected 1 failed URL, got %v", result.Failed)
+	}
+
+	// How each fetch went travels with the results
+	if fetch := result.Pages[0].Fetch; fetch.StatusCode != http.StatusOK || fetch.Bytes == 0 || fetch.Latency <= 0 {
+		t.Errorf("expected a successful fetch report, got %+v", fetch)
+	}
+	if fetch := result.Failed[0].Fetch; fetch.StatusCode != 0 || fetch.ErrorCategory == "" {
+		t.Errorf("expected a failed fetch report with an error category, got %+v", fetch)
+	}
+}
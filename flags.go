@@ -0,0 +1,15 @@
+package main
+
+import "strings"
+
+// stringListFlag collects a command line flag that may be repeated
+type stringListFlag []string
+
+func (f *stringListFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringListFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
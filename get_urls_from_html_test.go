@@ -1,149 +0,0 @@
-package main
-
-import (
-	"net/url"
-	"reflect"
-	"testing"
-)
-
-func TestGetURLsFromHTMLAbsolute(t *testing.T) {
-	inputURL := "https://blog.boot.dev"
-	inputBody := `<html><body><a href="https://blog.boot.dev"><span>Boot.dev</span></a></body></html>`
-
-	baseURL, err := url.Parse(inputURL)
-	if err != nil {
-		t.Errorf("couldn't parse input URL: %v", err)
-		return
-	}
-
-	actual, err := getURLsFromHTML(inputBody, baseURL)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-
-	expected := []string{"https://blog.boot.dev"}
-	if !reflect.DeepEqual(actual, expected) {
-		t.Errorf("expected %v, got %v", expected, actual)
-	}
-}
-
-func TestGetURLsFromHTMLRelative(t *testing.T) {
-	inputURL := "https://blog.boot.dev"
-	inputBody := `<html><body><a href="/path/to/page"><span>Page</span></a></body></html>`
-
-	baseURL, err := url.Parse(inputURL)
-	if err != nil {
-		t.Errorf("couldn't parse input URL: %v", err)
-		return
-	}
-
-	actual, err := getURLsFromHTML(inputBody, baseURL)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-
-	expected := []string{"https://blog.boot.dev/path/to/page"}
-	if !reflect.DeepEqual(actual, expected) {
-		t.Errorf("expected %v, got %v", expected, actual)
-	}
-}
-
-func TestGetURLsFromHTMLMultiple(t *testing.T) {
-	inputURL := "https://blog.boot.dev"
-	inputBody := `<html><body>
-		<a href="/path1">Link 1</a>
-		<a href="https://other.com">Link 2</a>
-		<a href="/path2">Link 3</a>
-	</body></html>`
-
-	baseURL, err := url.Parse(inputURL)
-	if err != nil {
-		t.Errorf("couldn't parse input URL: %v", err)
-		return
-	}
-
-	actual, err := getURLsFromHTML(inputBody, baseURL)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-
-	expected := []string{
-		"https://blog.boot.dev/path1",
-		"https://other.com",
-		"https://blog.boot.dev/path2",
-	}
-	if !reflect.DeepEqual(actual, expected) {
-		t.Errorf("expected %v, got %v", expected, actual)
-	}
-}
-
-func TestGetImagesFromHTMLRelative(t *testing.T) {
-	inputURL := "https://blog.boot.dev"
-	inputBody := `<html><body><img src="/logo.png" alt="Logo"></body></html>`
-
-	baseURL, err := url.Parse(inputURL)
-	if err != nil {
-		t.Errorf("couldn't parse input URL: %v", err)
-		return
-	}
-
-	actual, err := getImagesFromHTML(inputBody, baseURL)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-
-	expected := []string{"https://blog.boot.dev/logo.png"}
-	if !reflect.DeepEqual(actual, expected) {
-		t.Errorf("expected %v, got %v", expected, actual)
-	}
-}
-
-func TestGetImagesFromHTMLAbsolute(t *testing.T) {
-	inputURL := "https://blog.boot.dev"
-	inputBody := `<html><body><img src="https://example.com/image.jpg" alt="Image"></body></html>`
-
-	baseURL, err := url.Parse(inputURL)
-	if err != nil {
-		t.Errorf("couldn't parse input URL: %v", err)
-		return
-	}
-
-	actual, err := getImagesFromHTML(inputBody, baseURL)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-
-	expected := []string{"https://example.com/image.jpg"}
-	if !reflect.DeepEqual(actual, expected) {
-		t.Errorf("expected %v, got %v", expected, actual)
-	}
-}
-
-func TestGetImagesFromHTMLMultiple(t *testing.T) {
-	inputURL := "https://blog.boot.dev"
-	inputBody := `<html><body>
-		<img src="/img1.png" alt="Image 1">
-		<img src="https://cdn.com/img2.jpg" alt="Image 2">
-		<img src="/img3.gif">
-	</body></html>`
-
-	baseURL, err := url.Parse(inputURL)
-	if err != nil {
-		t.Errorf("couldn't parse input URL: %v", err)
-		return
-	}
-
-	actual, err := getImagesFromHTML(inputBody, baseURL)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-
-	expected := []string{
-		"https://blog.boot.dev/img1.png",
-		"https://cdn.com/img2.jpg",
-		"https://blog.boot.dev/img3.gif",
-	}
-	if !reflect.DeepEqual(actual, expected) {
-		t.Errorf("expected %v, got %v", expected, actual)
-	}
-}
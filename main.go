@@ -1,31 +1,102 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"net/url"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
 	"os"
 	"strconv"
-	"sync"
+	"time"
+
+	"WebCrawler/crawler"
 )
 
 func main() {
-	// Check if the correct number of arguments was provided
-	if len(os.Args) < 4 {
-		fmt.Println("Usage: ./crawler URL maxConcurrency maxPages")
-		fmt.Println("Example: ./crawler https://example.com 3 10")
+	// Optional scope rules, given before the positional arguments
+	var allowHosts, pathPrefixes, includePatterns, excludePatterns stringListFlag
+	flag.Var(&allowHosts, "allow-host", "host to crawl, `*.example.com` matches subdomains (repeatable, default: each seed's host)")
+	flag.Var(&pathPrefixes, "path-prefix", "only crawl paths starting with this `prefix` (repeatable)")
+	flag.Var(&includePatterns, "include", "only crawl URLs matching this `regexp` (repeatable)")
+	flag.Var(&excludePatterns, "exclude", "skip URLs matching this `regexp` (repeatable)")
+	var ignoreParams stringListFlag
+	flag.Var(&ignoreParams, "ignore-param", "glob `pattern` of query parameters that don't change the page but are still fetched (repeatable)")
+	var stripParams stringListFlag
+	flag.Var(&stripParams, "strip-param", "glob `pattern` of query parameters to remove before queueing URLs, on top of the tracking defaults (repeatable)")
+	keepTrackingParams := flag.Bool("keep-tracking-params", false, "don't strip the default tracking and session parameters (utm_*, fbclid, gclid, PHPSESSID, ...)")
+	ignoreQuery := flag.Bool("ignore-query", false, "treat URLs that differ only in their query string as one page")
+	keepScheme := flag.Bool("keep-scheme", false, "treat http:// and https:// URLs as separate pages")
+	keepDefaultPort := flag.Bool("keep-default-port", false, "don't drop :80 and :443 from URLs")
+	keepDotSegments := flag.Bool("keep-dot-segments", false, "don't resolve ./ and ../ path segments")
+	keepDuplicateSlashes := flag.Bool("keep-duplicate-slashes", false, "don't collapse // in paths")
+	keepIndexFile := flag.Bool("keep-index-file", false, "treat /dir/index.html and /dir/ as separate pages")
+	keepTrailingSlash := flag.Bool("keep-trailing-slash", false, "treat /dir/ and /dir as separate pages")
+	foldPathCase := flag.Bool("fold-path-case", false, "treat paths that differ only in case as one page")
+	skipNofollow := flag.Bool("skip-nofollow", false, "don't follow links marked rel=\"nofollow\"")
+	maxRecords := flag.Int("max-records", 0, "keep at most this many entries in each per-URL record of a site, such as out-of-scope URLs and content hashes (0 for no limit, or 100000 with -stream; -1 for no limit)")
+	canonicalAliases := flag.Bool("canonical-aliases", false, "crawl pages whose rel=canonical names another page as that page instead")
+	seedsFile := flag.String("seeds-file", "", "read extra seed URLs from this `file`, one \"URL [maxPages]\" per line (- for stdin)")
+	maxDuration := flag.Duration("max-duration", 0, "stop starting new pages after this long, e.g. 30m (0 for no limit)")
+	maxBytes := flag.Int64("max-bytes", 0, "stop starting new pages after downloading this many `bytes` (0 for no limit)")
+	maxPerHost := flag.Int("max-per-host", 0, "maximum concurrent requests per host within a site (0 for no limit)")
+	adaptive := flag.Bool("adaptive", false, "adjust each host's concurrency to its latency and errors, up to maxConcurrency (or -max-per-host)")
+	reportPerSite := flag.Bool("report-per-site", false, "write one report per site instead of one combined report")
+	maxPathDepth := flag.Int("max-path-depth", crawler.DefaultMaxPathDepth, "treat URLs with more path segments as traps (0 to disable)")
+	maxURLLength := flag.Int("max-url-length", crawler.DefaultMaxURLLength, "treat longer URLs as traps (0 to disable)")
+	maxRepeatedSegments := flag.Int("max-repeated-segments", crawler.DefaultMaxRepeatedSegments, "treat paths repeating one segment more often as traps (0 to disable)")
+	maxQueryVariants := flag.Int("max-query-variants", crawler.DefaultMaxQueryVariants, "treat further distinct query strings for one path as traps (0 to disable)")
+	nearDuplicateDistance := flag.Int("near-duplicate-distance", crawler.DefaultNearDuplicateDistance, "group pages whose content fingerprints differ by at most this many bits (0-64)")
+	jsonLines := flag.Bool("jsonl", false, "also write every page with its full link records to report.jsonl")
+	stream := flag.Bool("stream", false, "write each page to the report as soon as it is crawled instead of keeping results in memory")
+	visitedMode := flag.String("visited", "memory", "visited set implementation: memory, bloom or disk")
+	bloomCapacity := flag.Int("bloom-capacity", 1000000, "URLs per site the bloom visited set is sized for")
+	bloomFPRate := flag.Float64("bloom-fp-rate", 0.001, "false-positive rate of the bloom visited set")
+	visitedDir := flag.String("visited-dir", os.TempDir(), "`directory` for disk visited sets")
+	keepVisited := flag.Bool("keep-visited", false, "keep disk visited set files after the crawl instead of removing them")
+	coordinatorAddr := flag.String("coordinator", "", "serve the crawl to workers on this `address`, e.g. :8080, instead of fetching pages here")
+	leaseTimeout := flag.Duration("lease-timeout", crawler.DefaultLeaseTimeout, "reassign a worker's batch if it isn't submitted within this long")
+	workerURL := flag.String("worker", "", "fetch pages for the coordinator at this `URL`; only maxConcurrency is read from the arguments")
+	stateFile := flag.String("state", "", "incremental crawl: reuse unchanged pages saved in this `file` by the previous crawl, then save this crawl to it")
+	workerID := flag.String("worker-id", "", "name reported to the coordinator (default: hostname and process ID)")
+	flag.Usage = func() {
+		fmt.Println("Usage: ./crawler [options] URL [URL...] maxConcurrency maxPages")
+		fmt.Println("       ./crawler -worker coordinatorURL [maxConcurrency]")
+		fmt.Println("Example: ./crawler -allow-host '*.boot.dev' -exclude '/tag/' https://www.boot.dev 3 10")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	args := flag.Args()
+
+	// Distributed crawls don't send conditional requests or read sitemap lastmod dates, so they can't be incremental
+	if *stateFile != "" && (*coordinatorAddr != "" || *workerURL != "") {
+		fmt.Println("-state cannot be combined with -coordinator or -worker")
 		os.Exit(1)
 	}
 
-	if len(os.Args) > 4 {
-		fmt.Println("too many arguments provided")
-		fmt.Println("Usage: ./crawler URL maxConcurrency maxPages")
+	// Workers take their scope and budgets from the coordinator
+	if *workerURL != "" {
+		runWorker(*workerURL, *workerID, args)
+		return
+	}
+
+	// Check if the correct number of arguments was provided
+	minArgs := 3
+	if *seedsFile != "" {
+		minArgs = 2 // Seeds may all come from the file
+	}
+	if len(args) < minArgs {
+		fmt.Println("Usage: ./crawler [options] URL [URL...] maxConcurrency maxPages")
+		fmt.Println("Example: ./crawler https://example.com 3 10")
 		os.Exit(1)
 	}
 
 	// Get the arguments from command line
-	rawURL := os.Args[1]
-	maxConcurrencyStr := os.Args[2]
-	maxPagesStr := os.Args[3]
+	maxConcurrencyStr := args[len(args)-2]
+	maxPagesStr := args[len(args)-1]
 
 	// Parse maxConcurrency
 	maxConcurrency, err := strconv.Atoi(maxConcurrencyStr)
@@ -33,10 +104,6 @@ func main() {
 		fmt.Printf("error parsing maxConcurrency '%s': %v\n", maxConcurrencyStr, err)
 		os.Exit(1)
 	}
-	if maxConcurrency < 1 {
-		fmt.Println("maxConcurrency must be at least 1")
-		os.Exit(1)
-	}
 
 	// Parse maxPages
 	maxPages, err := strconv.Atoi(maxPagesStr)
@@ -44,50 +111,348 @@ func main() {
 		fmt.Printf("error parsing maxPages '%s': %v\n", maxPagesStr, err)
 		os.Exit(1)
 	}
-	if maxPages < 1 {
-		fmt.Println("maxPages must be at least 1")
+
+	if *nearDuplicateDistance < 0 || *nearDuplicateDistance > 64 {
+		fmt.Println("near-duplicate-distance must be between 0 and 64")
 		os.Exit(1)
 	}
 
-	// Parse the base URL
-	baseURL, err := url.Parse(rawURL)
-	if err != nil {
-		fmt.Printf("error parsing base URL: %v\n", err)
+	// Collect seeds from the command line and the seeds file
+	seeds := []crawler.Seed{}
+	for _, rawURL := range args[:len(args)-2] {
+		seeds = append(seeds, crawler.Seed{URL: rawURL})
+	}
+	if *seedsFile != "" {
+		fileSeeds, err := loadSeedsFile(*seedsFile)
+		if err != nil {
+			fmt.Printf("error reading seeds file: %v\n", err)
+			os.Exit(1)
+		}
+		seeds = append(seeds, fileSeeds...)
+	}
+
+	if *stream && *reportPerSite {
+		fmt.Println("-stream cannot be combined with -report-per-site")
+		os.Exit(1)
+	}
+	if *stream && *stateFile != "" {
+		fmt.Println("-stream cannot be combined with -state")
+		os.Exit(1)
+	}
+	if *stream && *jsonLines {
+		fmt.Println("-stream cannot be combined with -jsonl")
 		os.Exit(1)
 	}
 
-	fmt.Printf("starting crawl of: %s\n", rawURL)
-	fmt.Printf("maxConcurrency: %d\n", maxConcurrency)
-	fmt.Printf("maxPages: %d\n", maxPages)
+	opts := []crawler.Option{
+		crawler.WithMaxConcurrency(maxConcurrency),
+		crawler.WithMaxPages(maxPages),
+		crawler.WithMaxDuration(*maxDuration),
+		crawler.WithMaxBytes(*maxBytes),
+		crawler.WithAllowedHosts(allowHosts...),
+		crawler.WithPathPrefixes(pathPrefixes...),
+		crawler.WithInclude(includePatterns...),
+		crawler.WithExclude(excludePatterns...),
+		crawler.WithMaxPerHost(*maxPerHost),
+		crawler.WithAdaptiveConcurrency(*adaptive),
+		crawler.WithCanonicalAliases(*canonicalAliases),
+		crawler.WithSkipNofollow(*skipNofollow),
+		crawler.WithMaxRecords(*maxRecords),
+		crawler.WithURLNormalization(crawler.URLNormalization{
+			IgnoreQuery:        *ignoreQuery,
+			IgnoreParams:       ignoreParams,
+			StripParams:        stripParams,
+			KeepTrackingParams: *keepTrackingParams,
 
-	// Create the config struct per assignment
-	cfg := &config{
-		pages:              make(map[string]PageData),
-		baseURL:            baseURL,
-		mu:                 &sync.Mutex{},
-		concurrencyControl: make(chan struct{}, maxConcurrency),
-		wg:                 &sync.WaitGroup{},
-		maxPages:           maxPages,
+			KeepScheme:           *keepScheme,
+			KeepDefaultPort:      *keepDefaultPort,
+			KeepDotSegments:      *keepDotSegments,
+			KeepDuplicateSlashes: *keepDuplicateSlashes,
+			KeepIndexFile:        *keepIndexFile,
+			KeepTrailingSlash:    *keepTrailingSlash,
+			FoldPathCase:         *foldPathCase,
+		}),
+		crawler.WithTrapLimits(*maxPathDepth, *maxURLLength, *maxRepeatedSegments, *maxQueryVariants),
+		crawler.WithLogOutput(os.Stdout),
 	}
 
-	// wg.Add before spawning goroutine (as per assignment tips)
-	cfg.wg.Add(1)
-	go cfg.crawlPage(rawURL)
+	// Pick how each site remembers visited URLs
+	switch *visitedMode {
+	case "memory":
+	case "bloom":
+		opts = append(opts, crawler.WithVisitedSet(func(site string) (crawler.VisitedSet, error) {
+			return crawler.NewBloomVisitedSet(*bloomCapacity, *bloomFPRate)
+		}))
+	case "disk":
+		// Each run gets its own files, so crawls sharing -visited-dir never overwrite each other
+		opts = append(opts, crawler.WithVisitedSet(func(site string) (crawler.VisitedSet, error) {
+			return crawler.NewTempDiskVisitedSet(*visitedDir, "visited_"+crawler.SiteFilename(site)+"_*.db", *keepVisited)
+		}))
+	default:
+		fmt.Printf("unknown visited set '%s' (expected memory, bloom or disk)\n", *visitedMode)
+		os.Exit(1)
+	}
 
-	// Single wg.Wait() in main (as per assignment tips)
-	cfg.wg.Wait()
+	// An existing state file makes this an incremental recrawl
+	if *stateFile != "" {
+		previous, err := loadStateFile(*stateFile)
+		if err != nil {
+			fmt.Printf("error reading state file: %v\n", err)
+			os.Exit(1)
+		}
+		if previous != nil {
+			fmt.Printf("incremental crawl: %d pages from the previous crawl\n", len(previous))
+			opts = append(opts, crawler.WithPreviousResults(previous))
+		}
+	}
 
-	// Generate CSV report
+	// In streaming mode pages are written as they arrive and never kept in memory
 	filename := "report.csv"
-	fmt.Printf("\nGenerating CSV report: %s\n", filename)
+	var pageWriter *crawler.CSVPageWriter
+	var pageWriteErr error
+	if *stream {
+		pageWriter, err = crawler.NewCSVPageWriter(filename, len(seeds) > 1)
+		if err != nil {
+			fmt.Printf("error creating CSV report: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, crawler.WithPageHandler(func(page crawler.PageResult) {
+			if err := pageWriter.Write(page); err != nil && pageWriteErr == nil {
+				pageWriteErr = err
+			}
+		}))
+	}
 
-	err = writeCSVReport(cfg.pages, filename)
+	c, err := crawler.New(seeds, opts...)
 	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("maxConcurrency: %d\n", maxConcurrency)
+	fmt.Printf("maxPages: %d\n", maxPages)
+
+	// Cancelled on the first SIGINT/SIGTERM so we can still write a partial report
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handleShutdownSignals(cancel)
+
+	var result crawler.Result
+	if *coordinatorAddr != "" {
+		result, err = runCoordinator(ctx, c, *coordinatorAddr, *leaseTimeout)
+	} else {
+		result, err = c.Run(ctx)
+	}
+	if err != nil {
+		fmt.Printf("error crawling: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Finish the streamed report, renaming it if the crawl was interrupted
+	if pageWriter != nil {
+		if err := pageWriter.Close(); err != nil && pageWriteErr == nil {
+			pageWriteErr = err
+		}
+		if pageWriteErr != nil {
+			fmt.Printf("error writing CSV report: %v\n", pageWriteErr)
+			os.Exit(1)
+		}
+		if result.Partial {
+			if err := os.Rename(filename, crawler.PartialReportFilename(filename)); err != nil {
+				fmt.Printf("error marking CSV report as partial: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	// Generate CSV reports, marked as partial if the crawl was interrupted
+	if result.Partial {
+		fmt.Printf("\nGenerating CSV report: %s\n", crawler.PartialReportFilename(filename))
+	} else {
+		fmt.Printf("\nGenerating CSV report: %s\n", filename)
+	}
+	reportOpts := crawler.ReportOptions{
+		PerSite:               *reportPerSite,
+		NearDuplicateDistance: *nearDuplicateDistance,
+		Streamed:              *stream,
+		JSONLines:             *jsonLines,
+	}
+	if err := crawler.WriteReports(result, filename, reportOpts); err != nil {
 		fmt.Printf("error writing CSV report: %v\n", err)
 		os.Exit(1)
 	}
 
+	// Only save complete crawls, so an interrupted run doesn't forget pages it never reached
+	if *stateFile != "" && !result.Partial {
+		if err := saveStateFile(*stateFile, result); err != nil {
+			fmt.Printf("error writing state file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// Print basic summary
-	fmt.Printf("Crawl completed: %d pages found (max: %d)\n", len(cfg.pages), maxPages)
-	fmt.Printf("Report saved to %s\n", filename)
+	totalPages, totalOutOfScope, totalTraps, totalDropped := 0, 0, 0, 0
+	changeCounts := map[string]int{}
+	totalUnchanged := 0
+	for _, site := range result.Sites {
+		fmt.Printf("%s: %d pages found (max: %d)\n", site.Site, site.PageCount, site.MaxPages)
+		totalPages += site.PageCount
+		totalOutOfScope += len(site.OutOfScope)
+		totalTraps += len(site.Traps)
+		totalDropped += site.RecordsDropped
+		for _, change := range site.Changes {
+			changeCounts[change]++
+		}
+		totalUnchanged += site.Unchanged
+	}
+	if *stateFile != "" {
+		fmt.Printf("Since the previous crawl: %d new, %d changed, %d removed, %d unchanged pages\n",
+			changeCounts[crawler.ChangeNew], changeCounts[crawler.ChangeChanged], changeCounts[crawler.ChangeRemoved], totalUnchanged)
+	}
+	fmt.Println("\nCrawl statistics:")
+	if err := result.Stats.WriteSummary(os.Stdout); err != nil {
+		fmt.Printf("error printing statistics: %v\n", err)
+	}
+	fmt.Println()
+
+	if result.Partial {
+		fmt.Printf("Crawl interrupted: %d pages found across %d sites before shutdown\n", totalPages, len(result.Sites))
+		return
+	}
+	switch result.StopReason {
+	case crawler.StopMaxDuration:
+		fmt.Printf("Time budget of %v used up\n", *maxDuration)
+	case crawler.StopMaxBytes:
+		fmt.Printf("Download budget of %d bytes used up\n", *maxBytes)
+	case crawler.StopMaxPages:
+		fmt.Println("Page budget used up")
+	}
+	fmt.Printf("Crawl completed: %d pages found across %d sites\n", totalPages, len(result.Sites))
+	fmt.Printf("%d out-of-scope URLs recorded\n", totalOutOfScope)
+	fmt.Printf("%d crawler trap patterns cut off\n", totalTraps)
+	if totalDropped > 0 {
+		fmt.Printf("%d record entries dropped by -max-records\n", totalDropped)
+	}
+}
+
+// runWorker fetches pages for a coordinator until it reports the crawl is done
+func runWorker(coordinatorURL, id string, args []string) {
+	maxConcurrency := 3
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Printf("error parsing maxConcurrency '%s': %v\n", args[0], err)
+			os.Exit(1)
+		}
+		maxConcurrency = n
+	}
+
+	// The seed is only a placeholder, the coordinator hands out every URL
+	c, err := crawler.New([]crawler.Seed{{URL: coordinatorURL}}, crawler.WithMaxConcurrency(maxConcurrency), crawler.WithLogOutput(os.Stdout))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handleShutdownSignals(cancel)
+
+	fmt.Printf("working for coordinator: %s\n", coordinatorURL)
+	if err := c.NewWorker(coordinatorURL, id).Run(ctx); err != nil {
+		fmt.Printf("error working for coordinator: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// workerReleaseTimeout bounds how long the coordinator waits for workers to hear that the crawl is done
+const workerReleaseTimeout = 10 * time.Second
+
+// runCoordinator serves the crawl to workers on addr until it finishes
+func runCoordinator(ctx context.Context, c *crawler.Crawler, addr string, leaseTimeout time.Duration) (crawler.Result, error) {
+	co := c.NewCoordinator(leaseTimeout)
+	server := &http.Server{Addr: addr, Handler: co}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return crawler.Result{}, err
+	}
+
+	// Workers can't reach a coordinator whose server failed, so stop the crawl instead of waiting forever
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	serveErr := make(chan error, 1)
+	go func() {
+		err := server.Serve(listener)
+		if !errors.Is(err, http.ErrServerClosed) {
+			cancel()
+		}
+		serveErr <- err
+	}()
+	fmt.Printf("coordinating workers on: %s\n", listener.Addr())
+
+	result, err := co.Run(runCtx)
+
+	// Let polling workers hear that the crawl is done, but don't wait forever on workers that died
+	releaseCtx, cancelRelease := context.WithTimeout(context.Background(), workerReleaseTimeout)
+	defer cancelRelease()
+	if waitErr := co.WaitForWorkers(releaseCtx); waitErr != nil {
+		fmt.Printf("not every worker was told the crawl is done: %v\n", waitErr)
+	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), workerReleaseTimeout)
+	defer cancelShutdown()
+	shutdownErr := server.Shutdown(shutdownCtx)
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return result, fmt.Errorf("error serving workers: %w", err)
+	}
+	if err != nil {
+		return result, err
+	}
+	return result, shutdownErr
+}
+
+// loadStateFile reads the pages saved by the previous crawl, or nil if there is no state file yet
+func loadStateFile(path string) ([]crawler.PageResult, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return crawler.ReadState(file)
+}
+
+// saveStateFile replaces the state file with this crawl's pages
+func saveStateFile(path string, result crawler.Result) error {
+	// Write to a temporary file first so a failed write keeps the old state
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if err := crawler.WriteState(file, result); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// loadSeedsFile reads seeds from a file, or from stdin when path is "-"
+func loadSeedsFile(path string) ([]crawler.Seed, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+	return crawler.ReadSeeds(r)
 }
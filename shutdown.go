@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// handleShutdownSignals installs the SIGINT/SIGTERM handler for a crawl
+func handleShutdownSignals(cancel context.CancelFunc) {
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go watchShutdown(sigs, cancel, func() { os.Exit(130) })
+}
+
+// watchShutdown cancels the crawl on the first signal and calls forceExit on the second
+func watchShutdown(sigs <-chan os.Signal, cancel context.CancelFunc, forceExit func()) {
+	sig := <-sigs
+	fmt.Printf("\nreceived %v: finishing in-flight pages, press Ctrl+C again to exit immediately\n", sig)
+	cancel()
+
+	sig = <-sigs
+	fmt.Printf("received %v again: exiting without writing reports\n", sig)
+	forceExit()
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestWatchShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigs := make(chan os.Signal, 2)
+	exited := make(chan struct{})
+	go watchShutdown(sigs, cancel, func() { close(exited) })
+
+	// First signal should only cancel the crawl
+	sigs <- syscall.SIGINT
+	<-ctx.Done()
+
+	select {
+	case <-exited:
+		t.Fatal("expected first signal not to force an exit")
+	default:
+	}
+
+	// Second signal should force an exit
+	sigs <- syscall.SIGTERM
+	<-exited
+}